@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	for _, tc := range []struct{ b, want string }{
+		{a, ""},
+		{"a\nb\nc\nD\ne\nf\ng\nh\n", "--- old\n+++ new\n@@ -1,7 +1,7 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n"},
+		{"", "--- old\n+++ new\n@@ -1,8 +0,0 @@\n-a\n-b\n-c\n-d\n-e\n-f\n-g\n-h\n"},
+		{"x\n" + a, "--- old\n+++ new\n@@ -1,3 +1,4 @@\n+x\n a\n b\n c\n"},
+	} {
+		if got := string(unifiedDiff("old", "new", []byte(a), []byte(tc.b))); got != tc.want {
+			t.Errorf("unifiedDiff(%q, %q) =\n%s\nwant\n%s", a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -1,3 +1,7 @@
 module test-gen
 
-require golang.org/x/tools v0.0.0-20190202235157-7414d4c1f71c
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	golang.org/x/tools v0.0.0-20190202235157-7414d4c1f71c
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -2,36 +2,347 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/build"
+	"go/format"
+	"go/importer"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"go/types"
+	"io"
 	"io/ioutil"
 	"os"
+	pathpkg "path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
+	"unicode"
 
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/imports"
+	"gopkg.in/yaml.v3"
+
+	"test-gen/testgen"
 )
 
-const usage = `testgen <recv type> <iface>
+// version is the testgen version, set at link time with -ldflags "-X main.version=...".
+var version = "devel"
+
+const usage = `testgen [flags] <recv type> <iface> [out]
+testgen [flags] -recv <recv type> -iface <iface> [-o out]
+testgen [flags] -name <template> <iface>[,<iface>...] [out]
+testgen mock|stub [flags] <recv type> <iface> [out]
+testgen list|json [flags] <iface>
 testgen generates method stubs for recv to implement iface.
+The subcommands stub, list and json stand for -style stub, -list and -json,
+and mock for none; without one, the first argument is the receiver type.
+out and -o are relative to the current directory, or to $GOPATH/src with -gopath.
 Examples:
 testgen Test github.com/test/test.Test
+//go:generate testgen -recv Mock -iface io.Reader -o mock.go
+testgen -rname m Mock io.Reader
+testgen -diff Mock io.Reader mock.go
+testgen -missing File io.ReadWriteCloser
+testgen -style testify Mock io.ReadWriter
+testgen stub Server net/http.Handler
+testgen list io.ReadWriter
+testgen -name '{{.Iface}}Mock' io.Reader,io.Writer mocks.go
+testgen -header '// Code generated by testgen {{.Version}} from {{.Iface}}; DO NOT EDIT.' Mock io.Reader
+Flags:
 `
 
+// command is one run of testgen: its flags, where it writes, and the
+// packages it loads.
+type command struct {
+	*loader
+	flags          *flag.FlagSet
+	stdout, stderr io.Writer
+
+	// The values of the flags, except -concrete, which is a field of
+	// loader.
+	recvFlag       string
+	ifaceFlag      string
+	fileFlag       string
+	lineFlag       int
+	output         string
+	pkgName        string
+	recvName       string
+	nameTmpl       string
+	header         string
+	dir            string
+	watchMode      bool
+	verbose        bool
+	embedDirective bool
+	noGenHeader    bool
+	force          bool
+	diffOnly       bool
+	style          string
+	strict         bool
+	pointerZero    string
+	list           bool
+	jsonOut        bool
+	onlyMissing    bool
+	defaults       string
+	smartDefaults  bool
+	capture        bool
+	asserts        bool
+	lintSuppress   bool
+	comment        string
+	structComment  string
+	packageOut     string
+	delegate       bool
+	split          bool
+	tags           string
+	gopath         bool
+	expectClose    bool
+	spy            bool
+	pkgAll         string
+	specFile       string
+	zeroHelper     bool
+	logCalls       bool
+	match          string
+	skipMatch      string
+	syncMode       string
+	queue          bool
+	builder        bool
+	raw            bool
+	noFormat       bool
+	checkCompile   bool
+	appendMode     bool
+	local          string
+	embedIface     bool
+
+	pinned     importFlags // -import
+	only, skip nameFlags   // -only and -skip
+	specs      specFlags   // -spec and -spec-file
+	returnVals returnFlags // -return
+}
+
+// newCommand returns a command writing to stdout and stderr, with its
+// flags registered and set to their defaults.
+func newCommand(stdout, stderr io.Writer) *command {
+	c := &command{
+		loader:     newLoader(),
+		stdout:     stdout,
+		stderr:     stderr,
+		pinned:     make(importFlags),
+		returnVals: make(returnFlags),
+	}
+	fs := flag.NewFlagSet("testgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&c.recvFlag, "recv", "", "receiver type `name`, instead of the first argument")
+	fs.StringVar(&c.ifaceFlag, "iface", "", "`interface` to implement, instead of the second argument")
+	fs.StringVar(&c.fileFlag, "file", "", "Go `file` declaring the interface to implement at -line, instead of -iface")
+	fs.IntVar(&c.lineFlag, "line", 0, "`line` of the interface to implement in -file")
+	fs.StringVar(&c.output, "o", "", "output `file`, or directory to write mock_<recv>.go to; defaults to a file next to $GOFILE when run by go generate")
+	fs.StringVar(&c.pkgName, "pkg", "", "package `name` of the generated file; defaults to $GOPACKAGE when run by go generate")
+	fs.StringVar(&c.recvName, "rname", "t", "receiver variable name used in generated methods")
+	fs.StringVar(&c.nameTmpl, "name", "", "`template` of the receiver type name, with access to .Iface and .Pkg, e.g. '{{.Iface}}Mock', instead of the first argument; several comma-separated interfaces are then mocked into one file")
+	fs.StringVar(&c.header, "header", testgen.DefaultHeader, "`template` of the comment placed before the package clause, with access to .Iface, .Recv and .Version")
+	fs.StringVar(&c.dir, "dir", "", "`directory` to resolve import paths from, e.g. for vendored packages (default current directory)")
+	fs.BoolVar(&c.watchMode, "watch", false, "regenerate the output file whenever the files of the interface's package change, until interrupted")
+	fs.BoolVar(&c.verbose, "v", false, "log how the interface is resolved to stderr")
+	fs.BoolVar(&c.embedDirective, "embed-directive", true, "add a go:generate directive reproducing this invocation to the output file")
+	fs.BoolVar(&c.noGenHeader, "no-gen-header", false, "leave out the header, overriding -header, so the output is not marked as generated; overwriting it then requires -force")
+	fs.BoolVar(&c.force, "force", false, "overwrite the output file even if it is not a generated file")
+	fs.BoolVar(&c.diffOnly, "diff", false, "print a diff against the existing output file instead of writing it; exit 1 if they differ")
+	fs.StringVar(&c.style, "style", "mock", "style of the generated code: mock, testify for a github.com/stretchr/testify/mock mock, gomock for a github.com/golang/mock/gomock mock, or stub for methods panicking with not implemented, to start an implementation from")
+	fs.BoolVar(&c.strict, "strict", false, "panic in methods whose func is not set instead of returning zero values")
+	fs.StringVar(&c.pointerZero, "pointer-zero", "nil", "zero value of pointer results: nil, or alloc for a new value")
+	fs.BoolVar(&c.list, "list", false, "print the signatures of the interface's methods instead of generating code")
+	fs.BoolVar(&c.jsonOut, "json", false, "print the interface method set as JSON instead of generating code")
+	fs.BoolVar(&c.onlyMissing, "missing", false, "generate only the methods the existing recv type in the output package lacks")
+	fs.StringVar(&c.defaults, "defaults", "", "Go `file` declaring default results by type as var _ T = value, e.g. var _ time.Time = time.Now()")
+	fs.BoolVar(&c.smartDefaults, "smart-defaults", false, "default results of a named type T, or *T, to NewT() or Default() of its package if they return that type")
+	fs.BoolVar(&c.capture, "capture", false, "record the arguments of the calls to each method Foo in a FooCalls field")
+	fs.BoolVar(&c.asserts, "asserts", false, "generate AssertFooCalledWith methods checking the arguments of the last call to each method Foo; requires -capture")
+	fs.BoolVar(&c.lintSuppress, "lint-suppress", false, "add //nolint:all directives to the generated type and methods")
+	fs.StringVar(&c.comment, "comment", "", "`template` of the comments of generated methods the interface doesn't document, with access to .Name, .Iface and .Recv, e.g. '{{.Name}} implements {{.Iface}}.'")
+	fs.StringVar(&c.structComment, "struct-comment", "", "`template` of the comment of the generated type, with access to .Iface and .Recv")
+	fs.StringVar(&c.packageOut, "package-out", "", "write the mock, a doc.go and a New constructor as a package <iface>mock in `directory`, e.g. readermock for io.Reader")
+	fs.BoolVar(&c.delegate, "delegate", false, "generate a struct with a single Impl field of the interface type that methods delegate to, instead of a func per method")
+	fs.BoolVar(&c.split, "split", false, "implement the comma-separated interfaces of iface, writing the struct and the methods of each interface to separate files in the -o directory")
+	fs.StringVar(&c.tags, "tags", "", "comma-separated build `tags` to consider satisfied when loading packages; defaults to the -tags of $GOFLAGS")
+	fs.BoolVar(&c.gopath, "gopath", false, "resolve the positional out relative to $GOPATH/src, as older versions did")
+	fs.BoolVar(&c.expectClose, "expect-close", false, "add an ExpectClosed method reporting an error unless the Close method was called; requires -capture or -spy")
+	fs.BoolVar(&c.spy, "spy", false, "generate a spy recording calls like -capture and calling a Real implementation of the interface in methods whose func is not set")
+	fs.StringVar(&c.pkgAll, "pkg-all", "", "generate a mock named <Iface>Mock for each exported interface of the package at import `path` into the -o directory, as for -spec")
+	fs.StringVar(&c.specFile, "spec-file", "", "`file` of specs as for -spec, one recv=iface per line")
+	fs.BoolVar(&c.zeroHelper, "zero-helper", false, "return zero values of structs and other types without a literal zero value with a generic function instead of T{} or *new(T); requires Go 1.18")
+	fs.BoolVar(&c.logCalls, "log", false, "log the arguments of each call with a Logger field, or the standard logger if it is nil")
+	fs.StringVar(&c.match, "match", "", "generate only the methods whose names match the `regexp`")
+	fs.StringVar(&c.skipMatch, "skip-match", "", "leave out the methods whose names match the `regexp`")
+	fs.StringVar(&c.syncMode, "sync", "", "guard the recorded calls and queued results with a `mode` mutex: coarse for one per mock, fine for one per method")
+	fs.BoolVar(&c.queue, "queue", false, "add a FooReturns slice of results for each method Foo, returned in order by calls when FooFunc is not set")
+	fs.BoolVar(&c.builder, "builder", false, "add a WithFoo method setting FooFunc and returning the receiver for each method Foo, for chaining")
+	fs.BoolVar(&c.raw, "raw", false, "print the output of the templates as is, before goimports formats it and fixes its imports, to debug templates")
+	fs.BoolVar(&c.noFormat, "noformat", false, "format the output with gofmt instead of goimports, keeping the imports testgen tracked")
+	fs.BoolVar(&c.checkCompile, "compile-check", false, "type-check the generated code with the package it goes into, and fail with the compiler errors if it doesn't compile")
+	fs.BoolVar(&c.appendMode, "append", false, "add the methods of iface that the mock in the existing output file lacks to it instead of overwriting it")
+	fs.BoolVar(&c.concrete, "concrete", false, "implement the exported methods of iface if it is a concrete type, without asserting that recv can replace it")
+	fs.StringVar(&c.local, "local", "", "comma-separated import path `prefixes` of the project, whose imports goimports groups after the third-party ones")
+	fs.BoolVar(&c.embedIface, "embed-iface", false, "embed the interface in the generated struct and delegate to it in methods whose func is not set; calling such a method on a struct with a nil interface panics")
+
+	fs.Var(c.pinned, "import", "pin a package `name=path`, e.g. rand=crypto/rand; may be repeated")
+	fs.Var(&c.only, "only", "generate only the methods with these comma-separated `names`; may be repeated")
+	fs.Var(&c.skip, "skip", "leave out the methods with these comma-separated `names`; may be repeated")
+	fs.Var(&c.specs, "spec", "generate a mock of type recv implementing iface into the -o directory, given as `recv=iface`; may be repeated")
+	fs.Var(c.returnVals, "return", "set the default results of a method as `name=results`, e.g. Read=0,io.EOF; may be repeated")
+	c.flags = fs
+	return c
+}
+
+// warnf writes a warning to stderr.
+func (c *command) warnf(format string, args ...interface{}) {
+	fmt.Fprintf(c.stderr, "testgen: warning: "+format+"\n", args...)
+}
+
+// loader resolves interfaces and parses the packages declaring them.
+type loader struct {
+	// importDir is the directory import paths are resolved from, which
+	// matters for vendored packages and modules. See the -dir flag.
+	importDir string
+	// concrete allows implementing the methods of concrete types.
+	concrete bool
+	// logOut receives the -v log.
+	logOut io.Writer
+	// pkgCache holds the packages parsed so far, keyed by directory.
+	pkgCache map[string]*parsedPkg
+	// resolving holds the interfaces funcs is resolving, to detect
+	// interfaces embedding themselves.
+	resolving map[string]bool
+}
+
+// newLoader returns a loader resolving import paths from the current
+// directory, which doesn't log.
+func newLoader() *loader {
+	wd, _ := os.Getwd()
+	return &loader{
+		importDir: wd,
+		logOut:    ioutil.Discard,
+		pkgCache:  make(map[string]*parsedPkg),
+		resolving: make(map[string]bool),
+	}
+}
+
+// logf writes a line to logOut.
+func (l *loader) logf(format string, args ...interface{}) {
+	fmt.Fprintf(l.logOut, "testgen: "+format+"\n", args...)
+}
+
+// Error classes, which run maps to exit codes.
+var (
+	errUsage        = testgen.ErrUsage
+	errNotFound     = errors.New("interface not found")
+	errNotInterface = testgen.ErrNotInterface
+	errParse        = errors.New("couldn't parse interface")
+	errGenerate     = testgen.ErrGenerate
+)
+
+// Exit codes.
+const (
+	exitFailure      = 1 // other errors, or a -diff that differs
+	exitUsage        = 2
+	exitNotFound     = 3
+	exitNotInterface = 4
+	exitParse        = 5
+)
+
+// classed returns an error of the class class, one of the above, with
+// the message of err.
+func classed(err, class error) error { return testgen.Error{Err: err, Class: class} }
+
+// posError is an error in the source at pos, which prefixes its message.
+type posError struct {
+	pos token.Position
+	error
+}
+
+func (e posError) Error() string { return e.pos.String() + ": " + e.error.Error() }
+func (e posError) Unwrap() error { return e.error }
+
+// errorAt returns err at the position pos of p, unless err already has a
+// position, which is closer to its cause.
+func (p Pkg) errorAt(pos token.Pos, err error) error {
+	var perr posError
+	if errors.As(err, &perr) {
+		return err
+	}
+	return posError{p.FileSet.Position(pos), err}
+}
+
+// exitCode returns the exit code of err's class.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errNotFound):
+		return exitNotFound
+	case errors.Is(err, errNotInterface):
+		return exitNotInterface
+	case errors.Is(err, errParse), errors.Is(err, errGenerate):
+		return exitParse
+	}
+	return exitFailure
+}
+
 // findInterface returns the import path and identifier of an interface.
 // For example, given "http.ResponseWriter", findInterface returns
 // "net/http", "ResponseWriter".
 // If a fully qualified interface is given, such as "net/http.ResponseWriter",
 // it simply parses the input.
-func findInterface(iface string) (path string, id string, err error) {
+// A bare identifier, such as "Bar", is an interface of the package in
+// importDir, and a relative path, such as "./internal/svc.Service", is
+// resolved against the current directory.
+func (l *loader) findInterface(iface string) (path string, id string, err error) {
 	if len(strings.Fields(iface)) != 1 {
-		return "", "", fmt.Errorf("couldn't parse interface: %s", iface)
+		return "", "", classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
+	}
+
+	// A bare identifier names an interface of the package in importDir,
+	// e.g. with -dir internal/foo -iface Bar.
+	if token.IsIdentifier(iface) {
+		path, err := dirImportPath(l.importDir)
+		if err != nil {
+			return "", "", classed(fmt.Errorf("interface %s: %v", iface, err), errNotFound)
+		}
+		return path, iface, nil
+	}
+
+	// A relative path names the package in that directory, e.g.
+	// ./internal/svc.Service, whose import path comes from GOPATH or go.mod.
+	if strings.HasPrefix(iface, "./") || strings.HasPrefix(iface, "../") {
+		slash, dot := strings.LastIndex(iface, "/"), strings.LastIndex(iface, ".")
+		if dot < slash || !token.IsIdentifier(iface[dot+1:]) {
+			return "", "", classed(fmt.Errorf("invalid interface name: %s", iface), errParse)
+		}
+		dir, err := filepath.Abs(filepath.FromSlash(iface[:dot]))
+		if err != nil {
+			return "", "", classed(fmt.Errorf("interface %s: %v", iface, err), errNotFound)
+		}
+		if _, err := build.ImportDir(dir, build.FindOnly); err != nil {
+			return "", "", classed(fmt.Errorf("interface %s: %v", iface, err), errNotFound)
+		}
+		path, err := dirImportPath(dir)
+		if err != nil {
+			return "", "", classed(fmt.Errorf("interface %s: %v", iface, err), errNotFound)
+		}
+		return path, iface[dot+1:], nil
 	}
 
 	if slash := strings.LastIndex(iface, "/"); slash > -1 {
@@ -39,17 +350,37 @@ func findInterface(iface string) (path string, id string, err error) {
 		dot := strings.LastIndex(iface, ".")
 		// make sure iface does not end with "/" (e.g. reject net/http/)
 		if slash+1 == len(iface) {
-			return "", "", fmt.Errorf("interface name cannot end with a '/' character: %s", iface)
+			return "", "", classed(fmt.Errorf("interface name cannot end with a '/' character: %s", iface), errParse)
 		}
 		// make sure iface does not end with "." (e.g. reject net/http.)
 		if dot+1 == len(iface) {
-			return "", "", fmt.Errorf("interface name cannot end with a '.' character: %s", iface)
+			return "", "", classed(fmt.Errorf("interface name cannot end with a '.' character: %s", iface), errParse)
+		}
+		// make sure iface has a "." after "/" (e.g. reject net/http/httputil).
+		// The last path element may contain dots itself (e.g. gopkg.in/yaml.v3),
+		// but the identifier cannot, so it follows the last ".".
+		if dot < slash || !token.IsIdentifier(iface[dot+1:]) {
+			return "", "", classed(fmt.Errorf("invalid interface name: %s", iface), errParse)
+		}
+		path, id = iface[:dot], iface[dot+1:]
+		// make sure the "." doesn't belong to the package path
+		// (e.g. reject gopkg.in/yaml.v3)
+		if _, err := build.Import(path, l.importDir, build.FindOnly); err != nil {
+			if _, perr := build.Import(iface, l.importDir, build.FindOnly); perr == nil {
+				return "", "", classed(fmt.Errorf("missing interface name after package %s", iface), errParse)
+			}
+		}
+		return path, id, nil
+	}
+
+	if dot := strings.Index(iface, "."); dot > 0 {
+		path, err := l.stdPkg(iface[:dot], iface[dot+1:])
+		if err != nil {
+			return "", "", err
 		}
-		// make sure iface has exactly one "." after "/" (e.g. reject net/http/httputil)
-		if strings.Count(iface[slash:], ".") != 1 {
-			return "", "", fmt.Errorf("invalid interface name: %s", iface)
+		if path != "" {
+			return path, iface[dot+1:], nil
 		}
-		return iface[:dot], iface[dot+1:], nil
 	}
 
 	src := []byte("package hack\n" + "var i " + iface)
@@ -57,7 +388,7 @@ func findInterface(iface string) (path string, id string, err error) {
 	// auto fix the import path.
 	imp, err := imports.Process(".", src, nil)
 	if err != nil {
-		return "", "", fmt.Errorf("couldn't parse interface: %s", iface)
+		return "", "", classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
 	}
 
 	// imp should now contain an appropriate import.
@@ -65,58 +396,347 @@ func findInterface(iface string) (path string, id string, err error) {
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, "", imp, 0)
 	if err != nil {
-		panic(err)
+		return "", "", classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
 	}
 	if len(f.Imports) == 0 {
-		return "", "", fmt.Errorf("unrecognized interface: %s", iface)
+		return "", "", classed(fmt.Errorf("unrecognized interface: %s", iface), errNotFound)
 	}
 	raw := f.Imports[0].Path.Value   // "io"
 	path, err = strconv.Unquote(raw) // io
 	if err != nil {
-		panic(err)
+		return "", "", classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
+	}
+	// The input may have been any type, e.g. *io.Reader.
+	decl, ok := f.Decls[len(f.Decls)-1].(*ast.GenDecl) // var i io.Reader
+	if !ok || len(decl.Specs) != 1 {
+		return "", "", classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
+	}
+	spec, ok := decl.Specs[0].(*ast.ValueSpec) // i io.Reader
+	if !ok {
+		return "", "", classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
 	}
-	decl := f.Decls[1].(*ast.GenDecl)      // var i io.Reader
-	spec := decl.Specs[0].(*ast.ValueSpec) // i io.Reader
-	sel := spec.Type.(*ast.SelectorExpr)   // io.Reader
-	id = sel.Sel.Name                      // Reader
+	sel, ok := spec.Type.(*ast.SelectorExpr) // io.Reader
+	if !ok {
+		return "", "", classed(fmt.Errorf("invalid interface name: %s", iface), errParse)
+	}
+	id = sel.Sel.Name // Reader
 	return path, id, nil
 }
 
+// stdPkg returns the import path of the standard library package named
+// name that declares type id, or "" if there is none. Packages of the
+// same name, e.g. math/rand and crypto/rand, are told apart by id; it is
+// an error if several declare it, e.g. html/template and text/template
+// both declare FuncMap.
+func (l *loader) stdPkg(name, id string) (string, error) {
+	root := filepath.Join(build.Default.GOROOT, "src")
+	var paths []string
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		switch info.Name() {
+		case "internal", "vendor", "testdata", "cmd":
+			return filepath.SkipDir
+		}
+		if info.Name() == name {
+			rel, err := filepath.Rel(root, path)
+			if err == nil {
+				paths = append(paths, filepath.ToSlash(rel))
+			}
+		}
+		return nil
+	})
+	var found []string
+	for _, path := range paths {
+		if _, _, err := l.typeSpec(path, id); err == nil {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	return "", classed(fmt.Errorf("%s.%s is ambiguous, it is declared by %s; use its import path, e.g. %s.%[2]s",
+		name, id, strings.Join(found, " and "), found[0]), errUsage)
+}
+
 // Pkg is a parsed build.Package.
 type Pkg struct {
 	*build.Package
 	*token.FileSet
+	// loader loaded the package, and loads the packages it refers to.
+	*loader
+	// File is the file being processed, used to resolve its imports.
+	File *ast.File
+	// TypeParams holds the names of the type parameters of the interface
+	// being processed, which are not qualified.
+	TypeParams map[string]bool
 }
 
-// typeSpec locates the *ast.TypeSpec for type id in the import path.
-func typeSpec(path string, id string) (Pkg, *ast.TypeSpec, error) {
-	pkg, err := build.Import(path, "", 0)
+// parsedPkg is a build.Package together with its parsed files.
+type parsedPkg struct {
+	loader *loader // loader parsed the package
+	pkg    *build.Package
+	fset   *token.FileSet
+	files  []*ast.File
+}
+
+// loadPkg parses the package with the import path, which is resolved
+// relative to srcDir if it is a local path.
+func (l *loader) loadPkg(path, srcDir string) (*parsedPkg, error) {
+	pkg, err := build.Import(path, srcDir, 0)
 	if err != nil {
-		return Pkg{}, nil, fmt.Errorf("couldn't find package %s: %v", path, err)
+		return nil, fmt.Errorf("couldn't find package %s: %v", path, err)
+	}
+	if pp, ok := l.pkgCache[pkg.Dir]; ok {
+		return pp, nil
 	}
 
-	fset := token.NewFileSet() // share one fset across the whole package
+	l.logf("loading package %s from %s", pkg.ImportPath, pkg.Dir)
+	pp := &parsedPkg{loader: l, pkg: pkg, fset: token.NewFileSet()} // share one fset across the whole package
 	for _, file := range pkg.GoFiles {
-		f, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, file), nil, 0)
+		l.logf("parsing %s", filepath.Join(pkg.Dir, file))
+		// Types are looked up by name, so skip resolving identifiers to
+		// objects, which takes about a quarter of the parse time of large
+		// packages such as net/http, see BenchmarkParse.
+		f, err := parser.ParseFile(pp.fset, filepath.Join(pkg.Dir, file), nil, parser.ParseComments|parser.SkipObjectResolution)
 		if err != nil {
+			l.logf("skipping %s: %v", file, err)
 			continue
 		}
+		pp.files = append(pp.files, f)
+	}
+	l.pkgCache[pkg.Dir] = pp
+	return pp, nil
+}
+
+// typeSpec locates the *ast.TypeSpec for type id in the import path.
+func (l *loader) typeSpec(path string, id string) (Pkg, *ast.TypeSpec, error) {
+	pp, err := l.loadPkg(path, l.importDir)
+	if err != nil {
+		return Pkg{}, nil, err
+	}
+	return pp.typeSpec(id)
+}
 
+// typeSpec locates the *ast.TypeSpec for type id in pp.
+func (pp *parsedPkg) typeSpec(id string) (Pkg, *ast.TypeSpec, error) {
+	for _, f := range pp.files {
 		for _, decl := range f.Decls {
 			decl, ok := decl.(*ast.GenDecl)
 			if !ok || decl.Tok != token.TYPE {
 				continue
 			}
 			for _, spec := range decl.Specs {
-				spec := spec.(*ast.TypeSpec)
-				if spec.Name.Name != id {
+				spec, ok := spec.(*ast.TypeSpec)
+				if !ok || spec.Name.Name != id {
 					continue
 				}
-				return Pkg{Package: pkg, FileSet: fset}, spec, nil
+				return Pkg{Package: pp.pkg, FileSet: pp.fset, loader: pp.loader, File: f}, spec, nil
+			}
+		}
+	}
+	return Pkg{}, nil, fmt.Errorf("type %s not found in %s", id, pp.pkg.ImportPath)
+}
+
+// importPath returns the import path of the package named name in the
+// imports of p.File, or "" if there is none.
+func (p Pkg) importPath(name string) string {
+	if p.File == nil {
+		return ""
+	}
+	var unnamed []string
+	for _, imp := range p.File.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == name {
+				return path
+			}
+			continue
+		}
+		if pathpkg.Base(path) == name {
+			return path
+		}
+		unnamed = append(unnamed, path)
+	}
+	// The package name may differ from the last path element,
+	// e.g. gopkg.in/yaml.v3, so fall back to loading the packages.
+	for _, path := range unnamed {
+		if pkg, err := build.Import(path, p.Dir, 0); err == nil && pkg.Name == name {
+			return path
+		}
+	}
+	return ""
+}
+
+// dotImport returns the import path and name of the package dot-imported
+// by p.File that declares the exported type name, unless the package of p
+// declares it, or "" if there is none.
+func (p Pkg) dotImport(name string) (path, pkgName string) {
+	if p.File == nil || !token.IsExported(name) {
+		return "", ""
+	}
+	var dots []string
+	for _, imp := range p.File.Imports {
+		if imp.Name != nil && imp.Name.Name == "." {
+			if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+				dots = append(dots, path)
+			}
+		}
+	}
+	if len(dots) == 0 {
+		return "", ""
+	}
+	if pp, err := p.loadPkg(".", p.Dir); err == nil {
+		if _, _, err := pp.typeSpec(name); err == nil {
+			return "", ""
+		}
+	}
+	for _, path := range dots {
+		if pp, err := p.loadPkg(path, p.Dir); err == nil {
+			if _, _, err := pp.typeSpec(name); err == nil {
+				return path, pp.pkg.Name
+			}
+		}
+	}
+	return "", ""
+}
+
+// kind returns the kind of type e, such as "interface", "struct" or
+// "basic", following named types to their underlying type.
+// It returns "" if the kind cannot be determined.
+func (p Pkg) kind(e ast.Expr) string {
+	named := func(path, id string) string {
+		pp, err := p.loadPkg(path, p.Dir)
+		if err != nil {
+			return ""
+		}
+		p, spec, err := pp.typeSpec(id)
+		if err != nil {
+			return ""
+		}
+		return p.kind(spec.Type)
+	}
+
+	switch t := e.(type) {
+	case *ast.Ident:
+		if obj := types.Universe.Lookup(t.Name); obj != nil {
+			if types.IsInterface(obj.Type()) {
+				return "interface"
+			}
+			return "basic"
+		}
+		if path, _ := p.dotImport(t.Name); path != "" {
+			return named(path, t.Name)
+		}
+		return named(".", t.Name)
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return ""
+		}
+		path := p.importPath(x.Name)
+		if path == "" {
+			return ""
+		}
+		return named(path, t.Sel.Name)
+	case *ast.ParenExpr:
+		return p.kind(t.X)
+	case *ast.IndexExpr:
+		// instantiated generic type
+		return p.kind(t.X)
+	case *ast.IndexListExpr:
+		return p.kind(t.X)
+	case *ast.StarExpr:
+		return "pointer"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "slice"
+		}
+		return "array"
+	case *ast.MapType:
+		return "map"
+	case *ast.ChanType:
+		return "chan"
+	case *ast.FuncType:
+		return "func"
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	}
+	return ""
+}
+
+// named returns the kinds of the named types in e, keyed by the name
+// fullType gives them, and the import paths of the packages they belong to,
+// keyed by package name. It must be called before fullType rewrites e.
+func (p Pkg) named(e ast.Expr) (kinds, imports map[string]string) {
+	kinds = make(map[string]string)
+	imports = make(map[string]string)
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			// skip parameter names of func types
+			ast.Inspect(n.Type, inspect)
+			return false
+		case *ast.Ident:
+			if p.TypeParams[n.Name] {
+				kinds[n.Name] = "typeparam"
+				return true
+			}
+			name := n.Name
+			if path, pkgName := p.dotImport(n.Name); path != "" {
+				name = pkgName + "." + n.Name
+				imports[pkgName] = path
+			} else if n.IsExported() {
+				name = p.Package.Name + "." + n.Name
+				imports[p.Package.Name] = testgen.Unvendor(p.ImportPath)
+			}
+			kinds[name] = p.kind(n)
+		case *ast.SelectorExpr:
+			kinds[types.ExprString(n)] = p.kind(n)
+			if x, ok := n.X.(*ast.Ident); ok {
+				if path := p.importPath(x.Name); path != "" {
+					imports[x.Name] = path
+				}
+			}
+			return false
+		}
+		return true
+	}
+	ast.Inspect(e, inspect)
+	return kinds, imports
+}
+
+// ref returns the import path and name of the named type e, such as
+// "io.Reader", or "" if e is not a named type.
+func (p Pkg) ref(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		if path, _ := p.dotImport(t.Name); path != "" {
+			return testgen.Unvendor(path) + "." + t.Name
+		}
+		if types.Universe.Lookup(t.Name) == nil && !p.TypeParams[t.Name] {
+			return testgen.Unvendor(p.ImportPath) + "." + t.Name
+		}
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			if path := p.importPath(x.Name); path != "" {
+				return testgen.Unvendor(path) + "." + t.Sel.Name
 			}
 		}
+	case *ast.ParenExpr:
+		return p.ref(t.X)
 	}
-	return Pkg{}, nil, fmt.Errorf("type %s not found in %s", id, path)
+	return ""
 }
 
 // gofmt pretty-prints e.
@@ -128,63 +748,72 @@ func (p Pkg) gofmt(e ast.Expr) string {
 
 // fullType returns the fully qualified type of e.
 // Examples, assuming package net/http:
-// 	fullType(int) => "int"
-// 	fullType(Handler) => "http.Handler"
-// 	fullType(io.Reader) => "io.Reader"
-// 	fullType(*Request) => "*http.Request"
+//
+//	fullType(int) => "int"
+//	fullType(Handler) => "http.Handler"
+//	fullType(io.Reader) => "io.Reader"
+//	fullType(*Request) => "*http.Request"
+//	fullType(map[string][]*Cookie) => "map[string][]*http.Cookie"
+//	fullType(...Header) => "...http.Header"
+//	fullType(chan<- *Request) => "chan<- *http.Request"
+//
+// Only the exported identifiers are qualified, wherever they are nested.
 func (p Pkg) fullType(e ast.Expr) string {
-	ast.Inspect(e, func(n ast.Node) bool {
+	// Restore the renamed identifiers afterwards, so that the
+	// cached package is left untouched.
+	renamed := make(map[*ast.Ident]string)
+	defer func() {
+		for id, name := range renamed {
+			id.Name = name
+		}
+	}()
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
 		switch n := n.(type) {
+		case *ast.Field:
+			// skip the names of params, results and methods
+			ast.Inspect(n.Type, inspect)
+			return false
 		case *ast.Ident:
 			// Using typeSpec instead of IsExported here would be
 			// more accurate, but it'd be crazy expensive, and if
 			// the type isn't exported, there's no point trying
 			// to implement it anyway.
-			if n.IsExported() {
-				n.Name = p.Package.Name + "." + n.Name
+			if n.IsExported() && !p.TypeParams[n.Name] {
+				renamed[n] = n.Name
+				qual := p.Package.Name
+				if _, pkgName := p.dotImport(n.Name); pkgName != "" {
+					qual = pkgName
+				}
+				n.Name = qual + "." + n.Name
 			}
 		case *ast.SelectorExpr:
 			return false
 		}
 		return true
-	})
+	}
+	ast.Inspect(e, inspect)
 	return p.gofmt(e)
 }
 
-func (p Pkg) params(field *ast.Field) []Param {
-	var params []Param
+func (p Pkg) params(field *ast.Field) []testgen.Param {
+	var params []testgen.Param
+	kinds, imports := p.named(field.Type)
+	ref := p.ref(field.Type)
 	typ := p.fullType(field.Type)
+	_, variadic := field.Type.(*ast.Ellipsis)
 	for _, name := range field.Names {
-		params = append(params, Param{Name: name.Name, Type: typ})
+		params = append(params, testgen.Param{Name: name.Name, Type: typ, Variadic: variadic, Kinds: kinds, Imports: imports, Ref: ref})
 	}
 	// handle anonymous params
 	if len(params) == 0 {
-		params = []Param{{Type: typ}}
+		params = []testgen.Param{{Type: typ, Variadic: variadic, Kinds: kinds, Imports: imports, Ref: ref}}
 	}
 	return params
 }
 
-// Method represents a method signature.
-type Method struct {
-	Recv string
-	Func
-}
-
-// Func represents a function signature.
-type Func struct {
-	Name   string
-	Params []Param
-	Res    []Param
-}
-
-// Param represents a parameter in a function or method signature.
-type Param struct {
-	Name string
-	Type string
-}
-
-func (p Pkg) funcsig(f *ast.Field) Func {
-	fn := Func{Name: f.Names[0].Name}
+func (p Pkg) funcsig(f *ast.Field) testgen.Func {
+	fn := testgen.Func{Name: f.Names[0].Name, Doc: f.Doc.Text() + f.Comment.Text()}
 	typ := f.Type.(*ast.FuncType)
 	if typ.Params != nil {
 		for _, field := range typ.Params.List {
@@ -202,165 +831,2375 @@ func (p Pkg) funcsig(f *ast.Field) Func {
 // funcs returns the set of methods required to implement iface.
 // It is called funcs rather than methods because the
 // function descriptions are functions; there is no receiver.
-func funcs(iface string) (ifaceName string, path string, fns []Func, err error) {
+func (l *loader) funcs(iface string) (ifaceName, pkgName, path string, fns []testgen.Func, err error) {
+	if strings.HasPrefix(iface, "interface") {
+		ifaceName, fns, err := l.literalFuncs(iface)
+		return ifaceName, "", "", fns, err
+	}
+
 	// Locate the interface.
-	path, id, err := findInterface(iface)
+	path, id, err := l.findInterface(iface)
 	if err != nil {
-		return "", "", nil, err
+		return "", "", "", nil, err
+	}
+	l.logf("resolved %s to %s.%s", iface, path, id)
+	key := path + "." + id
+	if l.resolving[key] {
+		return "", "", "", nil, classed(fmt.Errorf("interface %s embeds itself", key), errParse)
 	}
+	l.resolving[key] = true
+	defer delete(l.resolving, key)
 
 	// Parse the package and find the interface declaration.
-	p, spec, err := typeSpec(path, id)
+	p, spec, err := l.typeSpec(path, id)
 	if err != nil {
-		return "", "", nil, fmt.Errorf("interface %s not found: %s", iface, err)
+		return "", "", "", nil, classed(fmt.Errorf("interface %s not found: %s", iface, err), errNotFound)
 	}
+	p, _ = p.withTypeParams(spec)
 	idecl, ok := spec.Type.(*ast.InterfaceType)
+	if !ok && l.concrete {
+		pp, err := l.loadPkg(path, l.importDir)
+		if err != nil {
+			return "", "", "", nil, err
+		}
+		return id, p.Name, testgen.Unvendor(path), pp.concreteFuncs(id), nil
+	}
 	if !ok {
-		return "", "", nil, fmt.Errorf("not an interface: %s", iface)
+		return "", "", "", nil, p.errorAt(spec.Pos(), classed(fmt.Errorf("not an interface: %s (use -concrete to implement its methods)", iface), errNotInterface))
 	}
 
+	// Marker interfaces without methods are implemented by an empty struct.
 	if idecl.Methods == nil {
-		return "", "", nil, fmt.Errorf("empty interface: %s", iface)
+		return id, p.Name, testgen.Unvendor(path), nil, nil
 	}
 
 	for _, fndecl := range idecl.Methods.List {
 		if len(fndecl.Names) == 0 {
+			if typeTerm(fndecl.Type) {
+				return "", "", "", nil, p.errorAt(fndecl.Pos(), constraintError(iface))
+			}
 			// Embedded interface: recurse
-			_, _, embedded, err := funcs(p.fullType(fndecl.Type))
+			l.logf("recursing into embedded interface %s of %s", p.fullType(fndecl.Type), iface)
+			embedded, err := p.embeddedFuncs(iface, fndecl.Type)
 			if err != nil {
-				return "", "", nil, err
+				return "", "", "", nil, p.errorAt(fndecl.Pos(), err)
 			}
 			fns = append(fns, embedded...)
 			continue
 		}
 
+		if err := methodField(iface, p, fndecl); err != nil {
+			return "", "", "", nil, p.errorAt(fndecl.Pos(), err)
+		}
 		fn := p.funcsig(fndecl)
 		fns = append(fns, fn)
 	}
-	return id, p.Name, fns, nil
+	return id, p.Name, testgen.Unvendor(path), fns, nil
 }
 
-var typeTmpl = `{{$recv := .Recv}}
-// Code generated by testgen; DO NOT EDIT.
-package {{ .Package }}
-// {{$recv}} ...
-type {{$recv}} struct {
-	{{range .Methods}}{{.Name}}Func func({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}})
-	{{end}}
+// embeddedFuncs returns the methods of the interface e embedded in iface.
+// The type arguments of an instantiated generic interface, e.g.
+// Store[string, int], replace its type parameters in the methods.
+func (p Pkg) embeddedFuncs(iface string, e ast.Expr) ([]testgen.Func, error) {
+	var args []testgen.Param
+	switch x := e.(type) {
+	case *ast.IndexExpr:
+		e, args = x.X, p.params(&ast.Field{Type: x.Index})
+	case *ast.IndexListExpr:
+		e = x.X
+		for _, index := range x.Indices {
+			args = append(args, p.params(&ast.Field{Type: index})...)
+		}
+	}
+	// The predeclared error interface has no source to parse.
+	if id, ok := e.(*ast.Ident); ok && id.Name == "error" && args == nil {
+		return []testgen.Func{{Name: "Error", Res: []testgen.Param{{Type: "string"}}}}, nil
+	}
+	name := p.fullType(e)
+	if id, ok := e.(*ast.Ident); ok {
+		if path, _ := p.dotImport(id.Name); path != "" {
+			name = path + "." + id.Name
+		}
+	}
+	_, _, _, fns, err := p.funcs(name)
+	if errors.Is(err, errNotInterface) {
+		return nil, constraintError(iface)
+	}
+	if err != nil || args == nil {
+		return fns, err
+	}
+	params := p.typeParams(name)
+	if len(params) != len(args) {
+		return nil, classed(fmt.Errorf("interface %s: %s has %d type parameters, got %d type arguments", iface, name, len(params), len(args)), errParse)
+	}
+	subst := make(map[string]testgen.Param)
+	for i, param := range params {
+		subst[param.Name] = args[i]
+	}
+	for i, fn := range fns {
+		fns[i].Params, fns[i].Res = instantiate(fn.Params, subst), instantiate(fn.Res, subst)
+	}
+	return fns, nil
 }
-{{range .Methods}}
-// {{.Name}} ...
-func (t *{{$recv}}){{.Name}}({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}}) {
-	if t.{{.Name}}Func != nil {
-		return t.{{.Name}}Func({{range .Params}}{{.Name}}{{ if variadic .Type }}...{{ end }}, {{end}})
+
+// instantiate returns params with the type parameters in their types
+// replaced by the type arguments in subst, keyed by parameter name.
+func instantiate(params []testgen.Param, subst map[string]testgen.Param) []testgen.Param {
+	var inst []testgen.Param
+	for _, param := range params {
+		typ := strings.TrimPrefix(param.Type, "...")
+		fset := token.NewFileSet()
+		e, err := parser.ParseExprFrom(fset, "", typ, 0)
+		if err != nil {
+			inst = append(inst, param)
+			continue
+		}
+		if arg, ok := subst[typ]; ok {
+			param.Ref = arg.Ref
+		}
+		kinds, imports := make(map[string]string), make(map[string]string)
+		for name, kind := range param.Kinds {
+			kinds[name] = kind
+		}
+		for name, path := range param.Imports {
+			imports[name] = path
+		}
+		var inspect func(n ast.Node) bool
+		inspect = func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Field:
+				// skip parameter names of func types
+				ast.Inspect(n.Type, inspect)
+				return false
+			case *ast.Ident:
+				arg, ok := subst[n.Name]
+				if !ok {
+					return true
+				}
+				delete(kinds, n.Name)
+				for name, kind := range arg.Kinds {
+					kinds[name] = kind
+				}
+				for name, path := range arg.Imports {
+					imports[name] = path
+				}
+				n.Name = arg.Type
+			case *ast.SelectorExpr:
+				return false
+			}
+			return true
+		}
+		ast.Inspect(e, inspect)
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, e)
+		param.Type = param.Type[:len(param.Type)-len(typ)] + buf.String()
+		param.Kinds, param.Imports = kinds, imports
+		inst = append(inst, param)
 	}
-	return {{$resLen := len .Res}}{{range $i, $e := .Res}}{{if eq $e.Type "error"}}nil{{else}}{{constructor .Type}}{{end}} {{if ne (plus1 $i) $resLen}},{{end}} {{end}}
+	return inst
 }
-{{end}}
-`
 
-var funcMapFunc = func(origType, receiver string) template.FuncMap {
-	return template.FuncMap{
-		"plus1": func(x int) int {
-			return x + 1
-		},
-		"constructor": func(typ string) string {
-			if typ == "int" || typ == "int16" || typ == "int32" || typ == "int64" ||
-				typ == "uint" || typ == "uint16" || typ == "uint32" || typ == "uint64" {
-				return "0"
+// concreteFuncs returns the exported methods declared on the concrete
+// type id, with value or pointer receivers, in the order of declaration.
+func (pp *parsedPkg) concreteFuncs(id string) []testgen.Func {
+	var fns []testgen.Func
+	for _, f := range pp.files {
+		p := Pkg{Package: pp.pkg, FileSet: pp.fset, loader: pp.loader, File: f}
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || !fd.Name.IsExported() {
+				continue
 			}
-			if typ == "bool" {
-				return "false"
+			t := fd.Recv.List[0].Type
+			if star, ok := t.(*ast.StarExpr); ok {
+				t = star.X
 			}
-			if strings.HasPrefix(typ, "*") {
-				return "&" + typ[1:] + "{}"
+			switch x := t.(type) {
+			case *ast.IndexExpr:
+				t = x.X
+			case *ast.IndexListExpr:
+				t = x.X
 			}
-
-			if typ == origType {
-				return receiver
+			if ident, ok := t.(*ast.Ident); !ok || ident.Name != id {
+				continue
 			}
-			return typ + "{}"
-		},
-		"variadic": func(typ string) bool {
-			return strings.HasPrefix(typ, "...")
-		},
+			fns = append(fns, p.funcsig(&ast.Field{Doc: fd.Doc, Names: []*ast.Ident{fd.Name}, Type: fd.Type}))
+		}
 	}
+	return fns
 }
 
-func genType(ifaceName, pkg, recvType string, fns []Func) []byte {
-	var typeTmplCompiled = template.Must(template.New("typeTmpl").Funcs(funcMapFunc(ifaceName, "t")).Parse(typeTmpl))
-
-	var buf bytes.Buffer
-	methods := make([]Method, len(fns))
-	for idx, fn := range fns {
-		methods[idx] = Method{Func: fn}
-	}
-
-	methodsStruct := struct {
-		Methods []Method
-		Recv    string
-		Package string
-	}{
-		Methods: methods,
-		Recv:    recvType,
-		Package: pkg,
-	}
-
-	if err := typeTmplCompiled.Execute(&buf, &methodsStruct); err != nil {
-		panic(err)
+// methodField returns an error unless the named element f of the
+// interface iface is a method, which funcsig can handle.
+func methodField(iface string, p Pkg, f *ast.Field) error {
+	if _, ok := f.Type.(*ast.FuncType); !ok || len(f.Names) != 1 {
+		return classed(fmt.Errorf("interface %s: unexpected element %s", iface, p.gofmt(f.Type)), errParse)
 	}
+	return nil
+}
 
-	pretty, err := imports.Process("", buf.Bytes(), nil)
-	if err != nil {
-		fmt.Println(buf.String())
-		fatal(err)
+// typeTerm reports whether the embedded element e of an interface is a
+// type term of a type constraint, such as ~int, int | string or
+// comparable, rather than an interface.
+func typeTerm(e ast.Expr) bool {
+	switch t := e.(type) {
+	case *ast.UnaryExpr, *ast.BinaryExpr:
+		return true
+	case *ast.Ident:
+		return t.Name != "error" && t.Name != "any" && types.Universe.Lookup(t.Name) != nil
 	}
+	return false
+}
 
-	return pretty
+// constraintError returns the error for a type constraint iface, which
+// has type terms and cannot be implemented.
+func constraintError(iface string) error {
+	return classed(fmt.Errorf("%s is a type constraint, not an implementable interface", iface), errNotInterface)
 }
 
-func main() {
-	if len(os.Args) < 3 {
-		fmt.Fprint(os.Stderr, usage)
-		os.Exit(2)
+// literalFuncs returns the methods of the interface type literal iface,
+// e.g. "interface{ Close() error }", and iface formatted.
+// The types in iface must be predeclared or qualified by their packages.
+func (l *loader) literalFuncs(iface string) (ifaceName string, fns []testgen.Func, err error) {
+	// iface is pasted into a file, so it must be a single expression.
+	if _, err := parser.ParseExpr(iface); err != nil {
+		return "", nil, classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
 	}
-	recvType, iface := os.Args[1], os.Args[2]
-
-	out := ""
-	if len(os.Args) == 4 {
-		out = os.Args[3]
+	// Let goimports add the imports of the packages iface refers to.
+	src, err := imports.Process(".", []byte("package hack\n"+"var i "+iface), nil)
+	if err != nil {
+		return "", nil, classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
 	}
-
-	ifaceName, pkg, fns, err := funcs(iface)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, 0)
 	if err != nil {
-		fatal(err)
+		return "", nil, classed(fmt.Errorf("couldn't parse interface: %s", iface), errParse)
 	}
-	ifaceName = pkg + "." + ifaceName
-
-	if out != "" {
-		out = filepath.Join(build.Default.GOPATH, "src", out)
-		_, pkg = filepath.Split(filepath.Dir(out))
+	decl := f.Decls[len(f.Decls)-1].(*ast.GenDecl) // var i interface{...}
+	idecl, ok := decl.Specs[0].(*ast.ValueSpec).Type.(*ast.InterfaceType)
+	if !ok {
+		return "", nil, classed(fmt.Errorf("not an interface: %s", iface), errNotInterface)
+	}
+	// Exported identifiers would be qualified by the package of the
+	// interface, which a literal doesn't have.
+	var unqualified string
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			ast.Inspect(n.Type, inspect)
+			return false
+		case *ast.Ident:
+			if n.IsExported() && unqualified == "" {
+				unqualified = n.Name
+			}
+		case *ast.SelectorExpr:
+			return false
+		}
+		return true
+	}
+	ast.Inspect(idecl, inspect)
+	if unqualified != "" {
+		return "", nil, classed(fmt.Errorf("type %s in interface literal must be qualified by its package", unqualified), errParse)
 	}
 
-	src := genType(ifaceName, pkg, recvType, fns)
+	p := Pkg{Package: &build.Package{Dir: l.importDir}, FileSet: fset, loader: l, File: f}
+	for _, field := range idecl.Methods.List {
+		if len(field.Names) == 0 {
+			if typeTerm(field.Type) {
+				return "", nil, constraintError(iface)
+			}
+			// Embedded interface: resolve it as if given on its own
+			embedded, err := p.embeddedFuncs(iface, field.Type)
+			if err != nil {
+				return "", nil, err
+			}
+			fns = append(fns, embedded...)
+			continue
+		}
+		if err := methodField(iface, p, field); err != nil {
+			return "", nil, err
+		}
+		fns = append(fns, p.funcsig(field))
+	}
+	return p.gofmt(idecl), fns, nil
+}
 
-	// write sources
-	if out == "" {
-		fmt.Print(string(src))
-		return
+// interfaceAt returns the interface declared in file around line, in the
+// form findInterface accepts.
+func interfaceAt(file string, line int) (string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return "", classed(err, errParse)
+	}
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			start := spec.Pos()
+			if !decl.Lparen.IsValid() {
+				start = decl.Pos() // include the type keyword
+			}
+			if line < fset.Position(start).Line || line > fset.Position(spec.End()).Line {
+				continue
+			}
+			if _, ok := spec.Type.(*ast.InterfaceType); !ok {
+				return "", classed(fmt.Errorf("%s:%d: %s is not an interface", file, line, spec.Name.Name), errNotInterface)
+			}
+			dir, err := filepath.Abs(filepath.Dir(file))
+			if err != nil {
+				return "", err
+			}
+			path, err := dirImportPath(dir)
+			if err != nil {
+				return "", err
+			}
+			return path + "." + spec.Name.Name, nil
+		}
 	}
+	return "", classed(fmt.Errorf("%s:%d: not inside an interface declaration", file, line), errNotFound)
+}
 
-	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
-		fatal(err)
+// packageSpecs returns a spec of a mock named <Iface>Mock for each
+// exported interface of the package with the import path, or in the
+// directory if path is relative. Type constraints are skipped.
+func (l *loader) packageSpecs(path string) ([]spec, error) {
+	if strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") {
+		dir, err := filepath.Abs(filepath.FromSlash(path))
+		if err != nil {
+			return nil, classed(err, errNotFound)
+		}
+		if path, err = dirImportPath(dir); err != nil {
+			return nil, classed(err, errNotFound)
+		}
 	}
-	if err := ioutil.WriteFile(out, src, 0655); err != nil {
-		fatal(err)
+	pp, err := l.loadPkg(path, l.importDir)
+	if err != nil {
+		return nil, classed(err, errNotFound)
 	}
+	var specs []spec
+	for _, f := range pp.files {
+		for _, decl := range f.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, ts := range decl.Specs {
+				ts := ts.(*ast.TypeSpec)
+				if _, ok := ts.Type.(*ast.InterfaceType); !ok || !ts.Name.IsExported() {
+					continue
+				}
+				iface := path + "." + ts.Name.Name
+				if _, _, _, _, err := l.funcs(iface); errors.Is(err, errNotInterface) {
+					l.logf("skipping %s: %v", iface, err)
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+				specs = append(specs, spec{ts.Name.Name + "Mock", iface})
+			}
+		}
+	}
+	if len(specs) == 0 {
+		return nil, classed(fmt.Errorf("no interfaces found in %s", path), errNotFound)
+	}
+	return specs, nil
+}
+
+// dirImportPath returns the import path of the package in dir, which is in
+// GOPATH or in a module.
+func dirImportPath(dir string) (string, error) {
+	if pkg, err := build.ImportDir(dir, build.FindOnly); err == nil && pkg.ImportPath != "." && !strings.HasPrefix(pkg.ImportPath, "_") {
+		return pkg.ImportPath, nil
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if mod, err := ioutil.ReadFile(filepath.Join(d, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(mod), "\n") {
+				if f := strings.Fields(line); len(f) == 2 && f[0] == "module" {
+					rel, err := filepath.Rel(d, dir)
+					if err != nil {
+						return "", err
+					}
+					return pathpkg.Join(strings.Trim(f[1], `"`), filepath.ToSlash(rel)), nil
+				}
+			}
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("couldn't determine the import path of %s", dir)
+		}
+	}
+}
+
+// generic reports whether iface has type parameters.
+func (l *loader) generic(iface string) bool {
+	return len(l.typeParams(iface)) > 0
+}
+
+// typeParams returns the type parameters of iface, whose types are their
+// constraints.
+func (l *loader) typeParams(iface string) []testgen.Param {
+	path, id, err := l.findInterface(iface)
+	if err != nil {
+		return nil
+	}
+	p, spec, err := l.typeSpec(path, id)
+	if err != nil {
+		return nil
+	}
+	_, params := p.withTypeParams(spec)
+	return params
+}
+
+// withTypeParams returns p set up for the type parameters of spec, and
+// those parameters with their constraints as types.
+func (p Pkg) withTypeParams(spec *ast.TypeSpec) (Pkg, []testgen.Param) {
+	if spec.TypeParams == nil {
+		return p, nil
+	}
+	p.TypeParams = make(map[string]bool)
+	for _, field := range spec.TypeParams.List {
+		for _, name := range field.Names {
+			p.TypeParams[name.Name] = true
+		}
+	}
+	var params []testgen.Param
+	for _, field := range spec.TypeParams.List {
+		params = append(params, p.params(field)...)
+	}
+	return p, params
+}
+
+// buildTags returns the comma-separated build tags in tags or, if it is
+// empty, the -tags flag of goflags, as in $GOFLAGS.
+func buildTags(tags, goflags string) []string {
+	if tags == "" {
+		for _, f := range strings.Fields(goflags) {
+			if strings.HasPrefix(f, "-tags=") || strings.HasPrefix(f, "--tags=") {
+				tags = f[strings.Index(f, "=")+1:]
+			}
+		}
+	}
+	var list []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			list = append(list, tag)
+		}
+	}
+	return list
+}
+
+// dirPackage returns the name of the package in dir, as declared by its
+// Go files other than external tests. For a directory without Go files it
+// returns the directory name stripped of characters not allowed in
+// identifiers, e.g. "mydir" for "my-dir".
+func dirPackage(dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil || strings.HasSuffix(f.Name.Name, "_test") {
+			continue
+		}
+		return f.Name.Name
+	}
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, filepath.Base(dir))
+	if !token.IsIdentifier(name) {
+		name = "p" + name
+	}
+	return name
+}
+
+// unexportedMethod returns the name of the first unexported method in fns.
+func unexportedMethod(fns []testgen.Func) string {
+	for _, fn := range fns {
+		if !ast.IsExported(fn.Name) {
+			return fn.Name
+		}
+	}
+	return ""
+}
+
+// unexportedType returns the first unexported, non-predeclared type
+// used by fns, together with the method using it.
+func unexportedType(fns []testgen.Func) (method, typ string) {
+	for _, fn := range fns {
+		for _, p := range append(fn.Params[:len(fn.Params):len(fn.Params)], fn.Res...) {
+			var names []string
+			for name := range p.Kinds {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if !strings.Contains(name, ".") && types.Universe.Lookup(name) == nil && p.Kinds[name] != "typeparam" {
+					return fn.Name, name
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+// methods returns the methods declared on type recv in the package in dir,
+// keyed by name. The file skip, if any, is ignored.
+func (l *loader) methods(dir, recv, skip string) (map[string]testgen.Func, error) {
+	pkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find package in %s: %v", dir, err)
+	}
+
+	fset := token.NewFileSet()
+	p := Pkg{Package: pkg, FileSet: fset, loader: l}
+	found := false
+	fns := make(map[string]testgen.Func)
+	for _, file := range pkg.GoFiles {
+		path := filepath.Join(pkg.Dir, file)
+		if skip != "" && path == skip {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if spec, ok := spec.(*ast.TypeSpec); ok && spec.Name.Name == recv {
+						found = true
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv == nil || len(decl.Recv.List) != 1 {
+					continue
+				}
+				typ := decl.Recv.List[0].Type
+				if star, ok := typ.(*ast.StarExpr); ok {
+					typ = star.X
+				}
+				// The type parameters of a generic receiver are not qualified.
+				var tparams []ast.Expr
+				switch x := typ.(type) {
+				case *ast.IndexExpr:
+					typ, tparams = x.X, []ast.Expr{x.Index}
+				case *ast.IndexListExpr:
+					typ, tparams = x.X, x.Indices
+				}
+				if id, ok := typ.(*ast.Ident); !ok || id.Name != recv {
+					continue
+				}
+				p.TypeParams = make(map[string]bool)
+				for _, tp := range tparams {
+					p.TypeParams[types.ExprString(tp)] = true
+				}
+				p.File = f
+				fns[decl.Name.Name] = p.funcsig(&ast.Field{Names: []*ast.Ident{decl.Name}, Type: decl.Type})
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("type %s not found in %s", recv, pkg.Dir)
+	}
+	return fns, nil
+}
+
+// missing returns the funcs in fns that are not already methods of recv.
+func missing(recv string, fns []testgen.Func, existing map[string]testgen.Func) ([]testgen.Func, error) {
+	var res []testgen.Func
+	for _, fn := range fns {
+		m, ok := existing[fn.Name]
+		if !ok {
+			res = append(res, fn)
+			continue
+		}
+		if !m.SameSignature(fn) {
+			return nil, fmt.Errorf("method %s.%s has a different signature than the interface", recv, fn.Name)
+		}
+	}
+	return res, nil
+}
+
+// appendMock merges the mock src of recv into the existing file old,
+// which declares the struct recv: the fields of src are added to the
+// struct unless it already has them, its methods and other declarations
+// are added at the end unless old declares methods of the same names, e.g.
+// Dump, and its imports to those of old. If old has go:generate
+// directives, the directive generating src, if any, is added after them,
+// so that go generate regenerates old and appends src again. The result is
+// formatted as cfg.Filename like src, with gofmt if cfg.NoFormat is set.
+func appendMock(old, src []byte, recv, directive string, cfg testgen.Config) ([]byte, error) {
+	if directive != "" && !bytes.Contains(old, []byte(directive+"\n")) {
+		hdr := old
+		if i := bytes.Index(old, []byte("\npackage ")); i >= 0 {
+			hdr = old[:i]
+		}
+		if i := bytes.LastIndex(hdr, []byte("//go:generate ")); i >= 0 {
+			end := i + bytes.IndexByte(old[i:], '\n')
+			old = append(append(append(old[:end:end], '\n'), directive...), old[end:]...)
+		}
+	}
+	fset := token.NewFileSet()
+	of, err := parser.ParseFile(fset, "old.go", old, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	nf, err := parser.ParseFile(fset, "new.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	structType := func(f *ast.File) (*ast.GenDecl, *ast.StructType) {
+		for _, decl := range f.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if st, ok := spec.Type.(*ast.StructType); ok && spec.Name.Name == recv {
+					return decl, st
+				}
+			}
+		}
+		return nil, nil
+	}
+	_, ost := structType(of)
+	if ost == nil {
+		return nil, fmt.Errorf("struct %s not found in the output file", recv)
+	}
+	ndecl, nst := structType(nf)
+
+	have := make(map[string]bool)
+	for _, field := range ost.Fields.List {
+		for _, name := range field.Names {
+			have[name.Name] = true
+		}
+	}
+	var fields bytes.Buffer
+	for _, field := range nst.Fields.List {
+		if len(field.Names) > 0 && have[field.Names[0].Name] {
+			continue
+		}
+		start, end := field.Pos(), field.End()
+		if field.Doc != nil {
+			start = field.Doc.Pos()
+		}
+		if field.Comment != nil {
+			end = field.Comment.End()
+		}
+		fields.WriteString("\t")
+		fields.Write(src[offset(start):offset(end)])
+		fields.WriteString("\n")
+	}
+
+	declared := make(map[string]bool)
+	for _, decl := range of.Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv != nil {
+			declared[fd.Name.Name] = true
+		}
+	}
+	var buf bytes.Buffer
+	closing := offset(ost.Fields.Closing)
+	buf.Write(old[:closing])
+	buf.Write(fields.Bytes())
+	buf.Write(old[closing:])
+	pos := offset(ndecl.End())
+	for _, decl := range nf.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Recv == nil || !declared[fd.Name.Name] {
+			continue
+		}
+		start := fd.Pos()
+		if fd.Doc != nil {
+			start = fd.Doc.Pos()
+		}
+		buf.Write(src[pos:offset(start)])
+		pos = offset(fd.End())
+	}
+	buf.Write(src[pos:])
+
+	merged, err := parser.ParseFile(fset, "merged.go", buf.Bytes(), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, imp := range nf.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := ""
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		astutil.AddNamedImport(fset, merged, name, path)
+	}
+	buf.Reset()
+	if err := format.Node(&buf, fset, merged); err != nil {
+		return nil, err
+	}
+	if cfg.NoFormat {
+		return buf.Bytes(), nil
+	}
+	return imports.Process(cfg.Filename, buf.Bytes(), nil)
+}
+
+// loadDefaults reads the default results declared in the Go file path as
+// blank variables, such as
+//
+//	var _ time.Time = time.Now()
+//
+// and returns the value expressions keyed by type. Types and values refer
+// to packages by their package names, as generated code does.
+func loadDefaults(path string) (map[string]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defaults := make(map[string]string)
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			spec := spec.(*ast.ValueSpec)
+			if spec.Type == nil || len(spec.Names) != 1 || spec.Names[0].Name != "_" || len(spec.Values) != 1 {
+				return nil, fmt.Errorf("%s: defaults must be declared as var _ T = value", path)
+			}
+			defaults[types.ExprString(spec.Type)] = types.ExprString(spec.Values[0])
+		}
+	}
+	return defaults, nil
+}
+
+// configFile is the name of the project config file, see loadConfig.
+const configFile = ".testgen.yaml"
+
+// Config is the contents of a config file: defaults of flags, keyed by
+// the flag names. Fields left out leave the defaults of their flags alone.
+type Config struct {
+	Style         *string `yaml:"style,omitempty"`
+	Header        *string `yaml:"header,omitempty"`
+	RecvName      *string `yaml:"rname,omitempty"`
+	PointerZero   *string `yaml:"pointer-zero,omitempty"`
+	Defaults      *string `yaml:"defaults,omitempty"`
+	Strict        *bool   `yaml:"strict,omitempty"`
+	Comment       *string `yaml:"comment,omitempty"`
+	StructComment *string `yaml:"struct-comment,omitempty"`
+	Capture       *bool   `yaml:"capture,omitempty"`
+	Asserts       *bool   `yaml:"asserts,omitempty"`
+	Tags          *string `yaml:"tags,omitempty"`
+	Local         *string `yaml:"local,omitempty"`
+	NoFormat      *bool   `yaml:"noformat,omitempty"`
+	SmartDefaults *bool   `yaml:"smart-defaults,omitempty"`
+	LintSuppress  *bool   `yaml:"lint-suppress,omitempty"`
+	Sync          *string `yaml:"sync,omitempty"`
+	NoGenHeader   *bool   `yaml:"no-gen-header,omitempty"`
+}
+
+// readConfig reads the config file path. Unknown settings are an error.
+func readConfig(path string) (Config, error) {
+	var cfg Config
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+		return cfg, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// setFlags sets the flags of fs named by the fields of cfg that are set.
+// Relative -defaults paths are made relative to dir.
+func (cfg Config) setFlags(fs *flag.FlagSet, dir string) error {
+	v := reflect.ValueOf(cfg)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsNil() {
+			continue
+		}
+		name := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		value := fmt.Sprint(v.Field(i).Elem().Interface())
+		if name == "defaults" && !filepath.IsAbs(value) {
+			value = filepath.Join(dir, value)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// loadConfig sets the defaults of the flags of fs from the nearest
+// .testgen.yaml in dir or its parents, if any, before the flags are
+// parsed. The file is a YAML mapping of flag names to values, see Config,
+// such as
+//
+//	style: testify
+//	header: "// Code generated by testgen; DO NOT EDIT.\n// +build !prod"
+//	defaults: testdata/defaults.go
+//
+// Relative -defaults paths are relative to the directory of the file.
+func loadConfig(fs *flag.FlagSet, dir string) error {
+	var path string
+	for {
+		path = filepath.Join(dir, configFile)
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+	cfg, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+	if err := cfg.setFlags(fs, dir); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// declares reports whether the package with the import path declares a
+// func fn without params returning only its type name, or a pointer to it
+// if ptr is set, for Config.Declares.
+func (l *loader) declares(path, fn, name string, ptr bool) bool {
+	pp, err := l.loadPkg(path, l.importDir)
+	return err == nil && pp.returns(fn, name, ptr)
+}
+
+// returns reports whether pp declares a func fn without params returning
+// only the type name of pp, or a pointer to it if ptr is set.
+func (pp *parsedPkg) returns(fn, name string, ptr bool) bool {
+	for _, f := range pp.files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv != nil || fd.Name.Name != fn || fd.Type.TypeParams != nil {
+				continue
+			}
+			if fd.Type.Params.NumFields() != 0 || fd.Type.Results.NumFields() != 1 {
+				return false
+			}
+			t := fd.Type.Results.List[0].Type
+			star, isPtr := t.(*ast.StarExpr)
+			if isPtr != ptr {
+				return false
+			}
+			if isPtr {
+				t = star.X
+			}
+			id, ok := t.(*ast.Ident)
+			return ok && id.Name == name
+		}
+	}
+	return false
+}
+
+// docTmpl generates the doc.go of a -package-out package.
+var docTmpl = `{{.Header}}
+
+// Package {{.Package}} provides {{.Recv}}, a mock of {{.Iface}}.
+package {{.Package}}
+{{if and (ne .Style "gomock") (not .Generic)}}
+// New returns a new {{.Recv}}, whose methods return zero values until
+// their funcs are set.
+func New() *{{.Recv}} {
+	return &{{.Recv}}{}
+}
+{{end}}`
+
+// genDoc returns the doc.go of a package pkg holding the mock recvType
+// of ifaceName. It has a New constructor, except for gomock mocks, which
+// come with their own, and generic mocks, which are instantiated instead.
+func genDoc(ifaceName, pkg, recvType string, cfg testgen.Config) ([]byte, error) {
+	var buf bytes.Buffer
+	err := template.Must(template.New("doc").Parse(docTmpl)).Execute(&buf, struct {
+		Header, Package, Recv, Iface, Style string
+		Generic                             bool
+	}{cfg.Header, pkg, recvType, ifaceName, cfg.Style, cfg.Generic})
+	if err != nil {
+		return nil, classed(err, errGenerate)
+	}
+	pretty, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, classed(fmt.Errorf("doc.go: %v", err), errGenerate)
+	}
+	return pretty, nil
+}
+
+// renderName executes the -name template text for the interface iface,
+// e.g. io.Reader, returning the name of the receiver type implementing it.
+func renderName(text, iface string) (string, error) {
+	tmpl, err := template.New("name").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid name: %v", err)
+	}
+	var pkg string
+	if dot := strings.LastIndex(iface, "."); dot >= 0 {
+		pkg, iface = pathpkg.Base(iface[:dot]), iface[dot+1:]
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Iface string
+		Pkg   string
+	}{
+		Iface: iface,
+		Pkg:   pkg,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid name: %v", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// renderHeader executes the header template text for a receiver type
+// implementing iface.
+func renderHeader(text, iface, recvType string) (string, error) {
+	tmpl, err := template.New("header").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid header: %v", err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Iface   string
+		Recv    string
+		Version string
+	}{
+		Iface:   iface,
+		Recv:    recvType,
+		Version: version,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid header: %v", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// generatedRx matches the comment marking a generated file,
+// see https://golang.org/s/generatedcode.
+var generatedRx = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// generated reports whether src has a generated code comment before
+// its package clause.
+func generated(src []byte) bool {
+	if i := bytes.Index(src, []byte("\npackage ")); i >= 0 {
+		src = src[:i]
+	}
+	return generatedRx.Match(src)
+}
+
+// importFlags is a flag.Value collecting name=path imports. The paths are
+// looked up once -dir is known.
+type importFlags map[string]string
+
+func (f importFlags) String() string {
+	var s []string
+	for name, path := range f {
+		s = append(s, name+"="+path)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+func (f importFlags) Set(v string) error {
+	eq := strings.Index(v, "=")
+	if eq < 0 {
+		return fmt.Errorf("expected name=path: %s", v)
+	}
+	name, path := v[:eq], v[eq+1:]
+	if !token.IsIdentifier(name) {
+		return fmt.Errorf("invalid package name: %s", name)
+	}
+	f[name] = path
+	return nil
+}
+
+// nameFlags is a list of names given as repeated or comma-separated flags.
+type nameFlags []string
+
+func (f *nameFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *nameFlags) Set(v string) error {
+	for _, name := range strings.Split(v, ",") {
+		if !token.IsIdentifier(name) {
+			return fmt.Errorf("invalid method name: %s", name)
+		}
+		*f = append(*f, name)
+	}
+	return nil
+}
+
+// subcommands maps the subcommands of testgen to the flags they stand
+// for. Other first arguments are receiver types, as before subcommands.
+var subcommands = map[string][]string{
+	"mock": nil,
+	"stub": {"-style=stub"},
+	"list": {"-list"},
+	"json": {"-json"},
+}
+
+// spec is a receiver type and the interface it implements.
+type spec struct {
+	recv, iface string
+}
+
+// specFlags is a flag.Value collecting specs given as recv=iface.
+type specFlags []spec
+
+func (f *specFlags) String() string {
+	var s []string
+	for _, spec := range *f {
+		s = append(s, spec.recv+"="+spec.iface)
+	}
+	return strings.Join(s, " ")
+}
+
+func (f *specFlags) Set(v string) error {
+	eq := strings.Index(v, "=")
+	if eq < 0 {
+		return fmt.Errorf("expected recv=iface: %s", v)
+	}
+	recv, iface := strings.TrimSpace(v[:eq]), strings.TrimSpace(v[eq+1:])
+	if !token.IsIdentifier(recv) {
+		return fmt.Errorf("invalid receiver type: %s", recv)
+	}
+	if iface == "" {
+		return fmt.Errorf("missing interface: %s", v)
+	}
+	*f = append(*f, spec{recv, iface})
+	return nil
+}
+
+// readFile adds the specs in the file path, one recv=iface per line.
+// Blank lines and lines starting with # are ignored.
+func (f *specFlags) readFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := f.Set(line); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, i+1, err)
+		}
+	}
+	return nil
+}
+
+// returnFlags is a flag.Value collecting the default results of methods,
+// given as name=expr,expr.
+type returnFlags map[string][]string
+
+func (f returnFlags) String() string {
+	var s []string
+	for name, vals := range f {
+		s = append(s, name+"="+strings.Join(vals, ", "))
+	}
+	sort.Strings(s)
+	return strings.Join(s, " ")
+}
+
+func (f returnFlags) Set(v string) error {
+	eq := strings.Index(v, "=")
+	if eq < 0 {
+		return fmt.Errorf("expected name=results: %s", v)
+	}
+	name := v[:eq]
+	if !token.IsIdentifier(name) {
+		return fmt.Errorf("invalid method name: %s", name)
+	}
+	// The results are parsed as the arguments of a call, so that commas
+	// inside them don't separate them.
+	e, err := parser.ParseExpr("f(" + v[eq+1:] + ")")
+	if err != nil {
+		return fmt.Errorf("invalid results of %s: %s", name, v[eq+1:])
+	}
+	call, ok := e.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return fmt.Errorf("invalid results of %s: %s", name, v[eq+1:])
+	}
+	var vals []string
+	for _, arg := range call.Args {
+		vals = append(vals, types.ExprString(arg))
+	}
+	f[name] = vals
+	return nil
+}
+
+// checkReturns returns an error unless each method in returns is in fns
+// and has as many results as its default results.
+func checkReturns(ifaceName string, fns []testgen.Func, returns map[string][]string) error {
+	res := make(map[string]int)
+	for _, fn := range fns {
+		res[fn.Name] = len(fn.Res)
+	}
+	var names []string
+	for name := range returns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		n, ok := res[name]
+		if !ok {
+			return classed(fmt.Errorf("%s has no method %s", ifaceName, name), errUsage)
+		}
+		if n != len(returns[name]) {
+			return classed(fmt.Errorf("-return %s: %s returns %d results, got %d", name, name, n, len(returns[name])), errUsage)
+		}
+	}
+	return nil
+}
+
+// compileFlag compiles the regexp expr of the flag name. The empty expr
+// gives nil.
+func compileFlag(name, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	rx, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, classed(fmt.Errorf("invalid -%s: %v", name, err), errUsage)
+	}
+	return rx, nil
+}
+
+// selectFuncs returns the funcs in fns named in only, if any, and
+// matching match, if not nil, and neither named in skip nor matching
+// skipMatch. Names of no func in fns are an error, as is selecting none.
+func selectFuncs(ifaceName string, fns []testgen.Func, only, skip []string, match, skipMatch *regexp.Regexp) ([]testgen.Func, error) {
+	names := make(map[string]bool)
+	for _, fn := range fns {
+		names[fn.Name] = true
+	}
+	for _, name := range append(append([]string(nil), only...), skip...) {
+		if !names[name] {
+			return nil, classed(fmt.Errorf("%s has no method %s", ifaceName, name), errUsage)
+		}
+	}
+	selected := func(name string, names []string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	var res []testgen.Func
+	for _, fn := range fns {
+		if (len(only) == 0 || selected(fn.Name, only)) && !selected(fn.Name, skip) &&
+			(match == nil || match.MatchString(fn.Name)) && (skipMatch == nil || !skipMatch.MatchString(fn.Name)) {
+			res = append(res, fn)
+		}
+	}
+	if len(res) == 0 {
+		return nil, classed(fmt.Errorf("no method of %s selected", ifaceName), errUsage)
+	}
+	return res, nil
+}
+
+// directive returns a go:generate directive regenerating the file out
+// of the directory dir, where the directive is, with the flags of this
+// invocation. recvType is empty if it is derived by -name.
+func (c *command) directive(recvType, iface, dir, out string) string {
+	quote := func(s string) string {
+		if s == "" || strings.ContainsAny(s, " \t\n\"\\") {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+	args := []string{"//go:generate", "testgen"}
+	c.flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "recv", "iface", "file", "line", "o", "package-out", "embed-directive", "diff", "force", "json", "list", "watch", "spec", "spec-file", "pkg-all":
+			return
+		}
+		// go generate runs in dir, so -dir is made relative to it.
+		if f.Name == "dir" {
+			rel := c.importDir
+			if abs, err := filepath.Abs(dir); err == nil {
+				if r, err := filepath.Rel(abs, c.importDir); err == nil {
+					rel = r
+				}
+			}
+			args = append(args, quote("-dir="+filepath.ToSlash(rel)))
+			return
+		}
+		if imps, ok := f.Value.(importFlags); ok {
+			for _, imp := range strings.Split(imps.String(), ",") {
+				args = append(args, "-import", quote(imp))
+			}
+			return
+		}
+		if rets, ok := f.Value.(returnFlags); ok {
+			var names []string
+			for name := range rets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				args = append(args, "-return", quote(name+"="+strings.Join(rets[name], ", ")))
+			}
+			return
+		}
+		args = append(args, quote("-"+f.Name+"="+f.Value.String()))
+	})
+	if recvType != "" {
+		args = append(args, "-recv", quote(recvType))
+	}
+	args = append(args, "-iface", quote(iface), "-o", quote(out))
+	return strings.Join(args, " ")
+}
+
+// watch runs testgen with args, which must not include -watch, and runs
+// it again whenever the Go files of the packages of the comma-separated
+// interfaces iface change, as fsnotify reports, except the files output
+// reports as its own. It only returns if the packages can't be watched.
+func (c *command) watch(iface string, args []string, output func(file string) bool) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	var paths []string
+	for _, name := range strings.Split(iface, ",") {
+		path, _, err := c.findInterface(name)
+		if err != nil {
+			return err
+		}
+		pkg, err := build.Import(path, c.importDir, build.FindOnly)
+		if err != nil {
+			return err
+		}
+		if err := w.Add(pkg.Dir); err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+	// Each run loads the packages afresh. Errors are reported by run.
+	run(args, c.stdout, c.stderr)
+	return regenerateOn(w.Events, w.Errors, output, 200*time.Millisecond, func() {
+		c.logf("regenerating after changes to the files of %s", strings.Join(paths, ", "))
+		run(args, c.stdout, c.stderr)
+	})
+}
+
+// regenerateOn calls regenerate after events changing Go files other than
+// tests and those skip reports, once no such event has arrived for the
+// debounce duration, so that files saved together are regenerated once.
+// It returns the first error of errs, or nil once events is closed.
+func regenerateOn(events <-chan fsnotify.Event, errs <-chan error, skip func(file string) bool, debounce time.Duration, regenerate func()) error {
+	var timer <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == fsnotify.Chmod || !strings.HasSuffix(ev.Name, ".go") || strings.HasSuffix(ev.Name, "_test.go") || skip(ev.Name) {
+				continue
+			}
+			timer = time.After(debounce)
+		case err := <-errs:
+			return err
+		case <-timer:
+			timer = nil
+			regenerate()
+		}
+	}
+}
+
+// withoutWatch returns args without -watch flags.
+func withoutWatch(args []string) []string {
+	var res []string
+	for _, arg := range args {
+		if name := strings.TrimLeft(arg, "-"); strings.HasPrefix(arg, "-") && (name == "watch" || strings.HasPrefix(name, "watch=")) {
+			continue
+		}
+		res = append(res, arg)
+	}
+	return res
+}
+
+// hasClose reports whether fns has a Close method without params.
+func hasClose(fns []testgen.Func) bool {
+	for _, fn := range fns {
+		if fn.Name == "Close" && len(fn.Params) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBuilder returns an error if the -builder method WithFoo of a
+// method Foo in fns would collide with another method of fns.
+func checkBuilder(fns []testgen.Func) error {
+	names := make(map[string]bool)
+	for _, fn := range fns {
+		names[fn.Name] = true
+	}
+	for _, fn := range fns {
+		if names["With"+fn.Name] {
+			return fmt.Errorf("builder method With%s collides with the method of the same name", fn.Name)
+		}
+	}
+	return nil
+}
+
+// checkAccess returns an error if the methods fns of ifaceName, declared in
+// package ifacePkg, cannot be implemented in package pkg.
+func checkAccess(ifaceName, ifacePkg, pkg string, fns []testgen.Func) error {
+	// Interface literals have no package.
+	if pkg == ifacePkg || ifacePkg == "" {
+		return nil
+	}
+	// Unexported methods can only be implemented in their own package.
+	if method := unexportedMethod(fns); method != "" {
+		return classed(fmt.Errorf("cannot implement sealed interface %s (unexported method %s); generate into package %s to implement it",
+			ifaceName, method, ifacePkg), errGenerate)
+	}
+	// Unexported types can only be referred to from their own package.
+	if method, typ := unexportedType(fns); typ != "" {
+		return classed(fmt.Errorf("method %s of %s uses unexported type %s.%s; generate into package %s (e.g. -pkg %s -o <file in %[3]s>) or export the type",
+			method, ifaceName, ifacePkg, typ, ifacePkg, ifacePkg), errGenerate)
+	}
+	return nil
+}
+
+// splitFiles generates a mock of recvType implementing ifaces in package pkg,
+// split into files keyed by name: the struct goes in a file named after
+// recvType, and the methods of each interface in a file named after recvType
+// and the interface. Methods shared by several interfaces go in the file of
+// the first. The header of the struct file is cfg.Header. The files are
+// formatted as files in dir.
+func (c *command) splitFiles(recvType string, ifaces []string, pkg, dir string, cfg testgen.Config) (map[string][]byte, error) {
+	type part struct {
+		name, path string
+		fns        []testgen.Func
+	}
+	var parts []part
+	var all []testgen.Func
+	seen := make(map[string]testgen.Func)
+	for _, iface := range ifaces {
+		id, ifacePkg, path, fns, err := c.funcs(iface)
+		if err != nil {
+			return nil, err
+		}
+		name := ifacePkg + "." + id
+		if err := checkAccess(name, ifacePkg, pkg, fns); err != nil {
+			return nil, err
+		}
+		var own []testgen.Func
+		for _, fn := range fns {
+			if f, ok := seen[fn.Name]; ok {
+				if !f.SameSignature(fn) {
+					return nil, fmt.Errorf("method %s of %s conflicts with the method of the same name of another interface", fn.Name, name)
+				}
+				continue
+			}
+			seen[fn.Name] = fn
+			own = append(own, fn)
+		}
+		parts = append(parts, part{name, path, own})
+		all = append(all, own...)
+	}
+
+	// Resolve the packages of all files together, so that they agree on
+	// the package names.
+	_, imps, _ := testgen.ResolveImports(all, cfg.Imports, testgen.Import{Name: strings.SplitN(parts[0].name, ".", 2)[0], Path: parts[0].path}, cfg.PkgPath, testgen.ReservedNames(cfg, all, recvType)...)
+	pinned := make(map[string]string)
+	for _, imp := range imps {
+		name := imp.Name
+		if name == "" {
+			name = pathpkg.Base(imp.Path)
+		}
+		pinned[name] = imp.Path
+	}
+	cfg.Imports = pinned
+
+	base := strings.ToLower(recvType)
+	files := make(map[string][]byte)
+	structCfg := cfg
+	structCfg.Part, structCfg.Filename = "struct", filepath.Join(dir, base+".go")
+	src, err := testgen.Generate(testgen.TypeTmpl, parts[0].name, parts[0].path, pkg, recvType, all, structCfg)
+	if err != nil {
+		return nil, err
+	}
+	files[base+".go"] = src
+	for _, p := range parts {
+		if len(p.fns) == 0 {
+			continue
+		}
+		file := base + "_" + strings.ToLower(p.name[strings.Index(p.name, ".")+1:]) + ".go"
+		if _, ok := files[file]; ok {
+			return nil, fmt.Errorf("interfaces named like %s would share the file %s", p.name, file)
+		}
+		hdr, err := renderHeader(c.header, p.name, recvType)
+		if err != nil {
+			return nil, err
+		}
+		methodsCfg := cfg
+		methodsCfg.Part, methodsCfg.Header, methodsCfg.Filename = "methods", hdr, filepath.Join(dir, file)
+		if files[file], err = testgen.Generate(testgen.TypeTmpl, p.name, p.path, pkg, recvType, p.fns, methodsCfg); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// multiMock generates a mock of each of ifaces, named by recvs, from tmpl
+// into one file of package pkg, or if pkg is empty of the package of the
+// first interface. The header of the file is cfg.Header.
+func (c *command) multiMock(tmpl string, ifaces, recvs []string, pkg string, cfg testgen.Config) ([]byte, error) {
+	type mock struct {
+		iface, name, path, recv string
+		fns                     []testgen.Func
+	}
+	var mocks []mock
+	var all []testgen.Func
+	seen := make(map[string]string)
+	for i, iface := range ifaces {
+		if other, ok := seen[recvs[i]]; ok {
+			return nil, classed(fmt.Errorf("-name gives %s and %s the same receiver type %s", other, iface, recvs[i]), errUsage)
+		}
+		seen[recvs[i]] = iface
+		id, ifacePkg, path, fns, err := c.funcs(iface)
+		if err != nil {
+			return nil, err
+		}
+		if pkg == "" {
+			pkg, cfg.PkgPath = ifacePkg, path
+		}
+		name := ifacePkg + "." + id
+		if err := checkAccess(name, ifacePkg, pkg, fns); err != nil {
+			return nil, err
+		}
+		if cfg.Style == "gomock" && c.generic(iface) {
+			return nil, classed(fmt.Errorf("-style gomock does not support generic interfaces: %s", iface), errUsage)
+		}
+		if cfg.ExpectClose && !hasClose(fns) {
+			return nil, classed(fmt.Errorf("-expect-close requires %s to have a Close method without params", name), errUsage)
+		}
+		if cfg.Builder {
+			if err := checkBuilder(fns); err != nil {
+				return nil, err
+			}
+		}
+		mocks = append(mocks, mock{iface, name, path, recvs[i], fns})
+		// The interfaces and their constraints are resolved with the
+		// methods, as the params of extra funcs.
+		self := testgen.Param{Type: name, Imports: map[string]string{ifacePkg: path}}
+		all = append(all, fns...)
+		all = append(all, testgen.Func{Params: []testgen.Param{self}}, testgen.Func{Params: c.typeParams(iface)})
+	}
+
+	// Resolve the packages of all mocks together, so that they agree on
+	// the package names.
+	_, imps, _ := testgen.ResolveImports(all, cfg.Imports, testgen.Import{}, cfg.PkgPath, testgen.ReservedNames(cfg, all, recvs...)...)
+	pinned := make(map[string]string)
+	for _, imp := range imps {
+		name := imp.Name
+		if name == "" {
+			name = pathpkg.Base(imp.Path)
+		}
+		pinned[name] = imp.Path
+	}
+	cfg.Imports = pinned
+
+	var srcs [][]byte
+	for i, m := range mocks {
+		mcfg := cfg
+		mcfg.Generic, mcfg.TypeParams = c.generic(m.iface), c.typeParams(m.iface)
+		if i > 0 {
+			mcfg.Header = ""
+		}
+		src, err := testgen.Generate(tmpl, m.name, m.path, pkg, m.recv, m.fns, mcfg)
+		if err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, src)
+	}
+	if cfg.Raw {
+		return bytes.Join(srcs, []byte("\n")), nil
+	}
+	return mergeFiles(srcs, cfg)
+}
+
+// mergeFiles merges the Go files srcs of one package into the first: the
+// declarations of the others are added at its end, and their imports to
+// its imports.
+func mergeFiles(srcs [][]byte, cfg testgen.Config) ([]byte, error) {
+	fset := token.NewFileSet()
+	var buf bytes.Buffer
+	buf.Write(srcs[0])
+	var files []*ast.File
+	for _, src := range srcs[1:] {
+		f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+		if err != nil {
+			return nil, classed(err, errGenerate)
+		}
+		files = append(files, f)
+		end := f.Name.End()
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.IMPORT {
+				end = decl.End()
+			}
+		}
+		buf.Write(src[fset.Position(end).Offset:])
+	}
+
+	merged, err := parser.ParseFile(fset, "merged.go", buf.Bytes(), parser.ParseComments)
+	if err != nil {
+		return nil, classed(err, errGenerate)
+	}
+	for _, f := range files {
+		for _, imp := range f.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			name := ""
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			astutil.AddNamedImport(fset, merged, name, path)
+		}
+	}
+	buf.Reset()
+	if err := format.Node(&buf, fset, merged); err != nil {
+		return nil, classed(err, errGenerate)
+	}
+	if cfg.NoFormat {
+		return buf.Bytes(), nil
+	}
+	return imports.Process(cfg.Filename, buf.Bytes(), nil)
+}
+
+// compileCheck type-checks the generated files srcs, keyed by name, as
+// files in dir, together with the files of the same package already there,
+// and returns the errors in srcs, if any.
+func compileCheck(dir string, srcs map[string][]byte) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range srcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range names {
+		f, err := parser.ParseFile(fset, filepath.Join(abs, name), srcs[name], 0)
+		if err != nil {
+			return classed(fmt.Errorf("generated code doesn't compile: %v", err), errGenerate)
+		}
+		files = append(files, f)
+	}
+	if pkg, err := build.ImportDir(abs, 0); err == nil {
+		for _, name := range append(pkg.GoFiles, pkg.TestGoFiles...) {
+			if _, ok := srcs[name]; ok {
+				continue
+			}
+			f, err := parser.ParseFile(fset, filepath.Join(abs, name), nil, 0)
+			if err == nil && f.Name.Name == files[0].Name.Name {
+				files = append(files, f)
+			}
+		}
+	}
+
+	var errs []string
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			if terr, ok := err.(types.Error); ok {
+				if _, ok := srcs[filepath.Base(fset.Position(terr.Pos).Filename)]; !ok {
+					return // not in generated code
+				}
+			}
+			errs = append(errs, err.Error())
+		},
+	}
+	conf.Check(files[0].Name.Name, fset, files, nil)
+	if len(errs) > 0 {
+		return classed(fmt.Errorf("generated code doesn't compile:\n%s", strings.Join(errs, "\n")), errGenerate)
+	}
+	return nil
+}
+
+// writeFile writes src to out, refusing to overwrite files that are not
+// generated unless -force is set. With -diff it prints how src differs from
+// out instead, and reports whether it does.
+func (c *command) writeFile(out string, src []byte) (differs bool, err error) {
+	if c.diffOnly {
+		old, err := ioutil.ReadFile(out)
+		if err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		d := unifiedDiff(out+".orig", out, old, src)
+		c.stdout.Write(d)
+		return d != nil, nil
+	}
+
+	if !c.force {
+		if old, err := ioutil.ReadFile(out); err == nil && !generated(old) {
+			return false, fmt.Errorf("refusing to overwrite %s: not a generated file (use -force to overwrite)", out)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return false, err
+	}
+	if err := ioutil.WriteFile(out, src, 0655); err != nil {
+		return false, err
+	}
+
+	fmt.Fprintf(c.stdout, "generated file: %s\n", out)
+	return false, nil
+}
+
+// ifaceJSON is the -json representation of an interface.
+type ifaceJSON struct {
+	Name    string         `json:"name"`
+	Package string         `json:"package"`
+	Path    string         `json:"path,omitempty"` // import path of Package
+	Methods []testgen.Func `json:"methods"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs testgen with the command line arguments args, writing the
+// generated code to stdout and errors to stderr, and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	c := newCommand(stdout, stderr)
+	if wd, err := os.Getwd(); err == nil {
+		if err := loadConfig(c.flags, wd); err != nil {
+			return c.fail(err)
+		}
+	}
+	cmdline := args
+	if len(args) > 0 {
+		if sub, ok := subcommands[args[0]]; ok {
+			args = append(append([]string(nil), sub...), args[1:]...)
+		}
+	}
+	if err := c.flags.Parse(args); err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return exitUsage
+	}
+	if c.verbose {
+		c.logOut = stderr
+	}
+	// Tag-gated files declaring the interface are loaded like go build would.
+	build.Default.BuildTags = buildTags(c.tags, os.Getenv("GOFLAGS"))
+	imports.LocalPrefix = c.local
+	if c.dir != "" {
+		abs, err := filepath.Abs(c.dir)
+		if err != nil {
+			return c.fail(err)
+		}
+		c.importDir = abs
+	}
+	// Pinned packages are looked up from -dir, wherever it is given.
+	var names []string
+	for name := range c.pinned {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := c.pinned[name]
+		if _, err := build.Import(path, c.importDir, build.FindOnly); err != nil {
+			return c.failUsage(fmt.Sprintf("invalid value %q for flag -import: couldn't find package %s: %v", name+"="+path, path, err))
+		}
+	}
+
+	recvType, iface, out := c.recvFlag, c.ifaceFlag, filepath.Clean(c.output)
+	if c.output == "" {
+		out = ""
+	}
+	if c.fileFlag != "" {
+		if iface != "" {
+			return c.failUsage("-file cannot be used with -iface")
+		}
+		var err error
+		if iface, err = interfaceAt(c.fileFlag, c.lineFlag); err != nil {
+			return c.fail(err)
+		}
+	}
+	args = c.flags.Args()
+	// The receiver type doesn't matter when listing the methods, so the
+	// only argument may be the interface.
+	if (c.list || c.jsonOut) && recvType == "" && iface == "" && c.nameTmpl == "" && len(args) == 1 {
+		recvType = "Mock"
+	}
+	matchRx, err := compileFlag("match", c.match)
+	if err != nil {
+		return c.fail(err)
+	}
+	skipMatchRx, err := compileFlag("skip-match", c.skipMatch)
+	if err != nil {
+		return c.fail(err)
+	}
+	selecting := len(c.only) > 0 || len(c.skip) > 0 || matchRx != nil || skipMatchRx != nil
+	// Specs give the receiver types and interfaces of several mocks,
+	// each written to its own file, and the only argument is the
+	// output directory.
+	var recvs, specIfaces []string
+	if c.specFile != "" {
+		if err := c.specs.readFile(c.specFile); err != nil {
+			return c.fail(classed(err, errUsage))
+		}
+	}
+	// -pkg-all gives a spec for each interface of a package, after those
+	// of -spec.
+	pkgAllFrom := len(c.specs)
+	if c.pkgAll != "" {
+		pkgSpecs, err := c.packageSpecs(c.pkgAll)
+		if err != nil {
+			return c.fail(err)
+		}
+		c.specs = append(c.specs, pkgSpecs...)
+	}
+	specMode := len(c.specs) > 0
+	if specMode {
+		if recvType != "" || iface != "" || c.nameTmpl != "" || c.split || c.onlyMissing || c.appendMode || c.packageOut != "" || c.jsonOut || c.list || selecting {
+			return c.failUsage("-spec and -pkg-all cannot be used with -recv, -iface, -file, -name, -split, -missing, -append, -package-out, -json, -list, -only, -skip, -match or -skip-match")
+		}
+		for _, spec := range c.specs {
+			recvs, specIfaces = append(recvs, spec.recv), append(specIfaces, spec.iface)
+		}
+		recvType, iface = recvs[0], strings.Join(specIfaces, ",")
+	}
+	if recvType == "" && c.nameTmpl == "" && len(args) > 0 {
+		recvType, args = args[0], args[1:]
+	}
+	if iface == "" && len(args) > 0 {
+		iface, args = args[0], args[1:]
+	}
+	if (recvType == "" && c.nameTmpl == "") || iface == "" || len(args) > 1 {
+		c.flags.Usage()
+		return exitUsage
+	}
+	// The receiver types of -name are derived from the interfaces, of
+	// which there may be several, each getting its own mock in one file.
+	multi := c.nameTmpl != "" && strings.Contains(iface, ",")
+	if c.nameTmpl != "" {
+		if recvType != "" || c.split || strings.HasPrefix(iface, "interface") {
+			return c.failUsage("-name requires named interfaces, and cannot be used with -recv or -split")
+		}
+		for _, name := range strings.Split(iface, ",") {
+			recv, err := renderName(c.nameTmpl, name)
+			if err != nil {
+				return c.fail(classed(err, errUsage))
+			}
+			if !token.IsIdentifier(recv) {
+				return c.failUsage(fmt.Sprintf("invalid receiver type: %s", recv))
+			}
+			recvs = append(recvs, recv)
+		}
+		recvType = recvs[0]
+	}
+	if len(args) == 1 {
+		out = filepath.Clean(args[0])
+		if c.gopath {
+			out = filepath.Join(build.Default.GOPATH, "src", out)
+		}
+	}
+
+	// The receiver type may be qualified by the package it is declared in,
+	// which must be the package generated into.
+	var recvPkg string
+	if dot := strings.Index(recvType, "."); dot >= 0 {
+		recvPkg = recvType[:dot]
+		if !token.IsIdentifier(recvPkg) || !token.IsIdentifier(recvType[dot+1:]) {
+			return c.failUsage(fmt.Sprintf("invalid receiver type: %s", recvType))
+		}
+		recvType = recvType[dot+1:]
+	}
+	if !token.IsIdentifier(recvType) {
+		return c.failUsage(fmt.Sprintf("invalid receiver type: %s", recvType))
+	}
+	checkRecvPkg := func(pkg string) error {
+		if recvPkg != "" && pkg != recvPkg {
+			return classed(fmt.Errorf("receiver %s.%s cannot be declared in package %s", recvPkg, recvType, pkg), errUsage)
+		}
+		return nil
+	}
+
+	// A split mock is written to a directory, by default the current one.
+	if c.split {
+		if out == "" {
+			out = "."
+		}
+		if fi, err := os.Stat(out); err != nil || !fi.IsDir() {
+			return c.failUsage("-split requires -o to be a directory")
+		}
+	} else if specMode {
+		if out == "" {
+			out = "."
+		}
+		if fi, err := os.Stat(out); err != nil || !fi.IsDir() {
+			return c.failUsage("-spec and -pkg-all require -o to be a directory")
+		}
+	} else if strings.Contains(iface, ",") && !multi && !strings.HasPrefix(iface, "interface") {
+		return c.failUsage("implementing several interfaces requires -split or -name")
+	}
+
+	// When run by go generate, write next to the file containing
+	// the directive unless told otherwise.
+	stem := strings.ToLower(recvType)
+	if multi {
+		stem = "mocks"
+	}
+	if gofile := os.Getenv("GOFILE"); out == "" && gofile != "" {
+		out = strings.TrimSuffix(gofile, ".go") + "_" + stem + ".go"
+	}
+	// An output directory gets a file named after the receiver type.
+	if fi, err := os.Stat(out); out != "" && !c.split && !specMode && err == nil && fi.IsDir() {
+		out = filepath.Join(out, "mock_"+stem+".go")
+	}
+
+	// Resolve the interface's package with the pinned imports.
+	if dot := strings.Index(iface, "."); dot > 0 && !strings.Contains(iface, "/") {
+		if path, ok := c.pinned[iface[:dot]]; ok {
+			iface = path + iface[dot:]
+		}
+	}
+
+	if c.packageOut != "" && (c.output != "" || len(args) == 1 || c.split || strings.HasPrefix(iface, "interface")) {
+		return c.failUsage("-package-out requires a named interface and cannot be used with an output file or -split")
+	}
+	if c.diffOnly && out == "" && c.packageOut == "" {
+		return c.failUsage("-diff requires an output file")
+	}
+	if c.syncMode != "" && c.syncMode != "coarse" && c.syncMode != "fine" {
+		return c.failUsage(fmt.Sprintf("invalid -sync: %s", c.syncMode))
+	}
+	if c.pointerZero != "nil" && c.pointerZero != "alloc" {
+		return c.failUsage(fmt.Sprintf("invalid -pointer-zero: %s", c.pointerZero))
+	}
+	if !token.IsIdentifier(c.recvName) {
+		return c.failUsage(fmt.Sprintf("invalid receiver name: %s", c.recvName))
+	}
+	var defs map[string]string
+	if c.defaults != "" {
+		var err error
+		if defs, err = loadDefaults(c.defaults); err != nil {
+			return c.fail(err)
+		}
+	}
+	if c.embedIface && (c.style != "mock" || c.onlyMissing || strings.HasPrefix(iface, "interface")) {
+		return c.failUsage("-embed-iface requires -style mock and a named interface, and cannot be used with -missing")
+	}
+	if c.split && (c.style != "mock" || c.onlyMissing || c.embedIface || c.jsonOut || c.list) {
+		return c.failUsage("-split requires -style mock and cannot be used with -missing, -embed-iface, -json or -list")
+	}
+	if c.delegate && (c.style != "mock" || c.onlyMissing || c.embedIface || c.capture || c.split) {
+		return c.failUsage("-delegate requires -style mock and cannot be used with -missing, -embed-iface, -capture or -split")
+	}
+	if c.capture && (c.style != "mock" || c.onlyMissing) {
+		return c.failUsage("-capture requires -style mock and cannot be used with -missing")
+	}
+	if c.concrete && (c.embedIface || c.delegate || c.split) {
+		return c.failUsage("-concrete cannot be used with -embed-iface, -delegate or -split")
+	}
+	if c.appendMode && (c.style != "mock" || c.onlyMissing || c.embedIface || c.delegate || c.split || c.packageOut != "" || out == "") {
+		return c.failUsage("-append requires -style mock and an output file, and cannot be used with -missing, -embed-iface, -delegate, -split or -package-out")
+	}
+	if c.spy && (c.style != "mock" || c.onlyMissing || c.embedIface || c.delegate || c.concrete) {
+		return c.failUsage("-spy requires -style mock and cannot be used with -missing, -embed-iface, -delegate or -concrete")
+	}
+	if c.queue && (c.style != "mock" || c.onlyMissing || c.delegate) {
+		return c.failUsage("-queue requires -style mock and cannot be used with -missing or -delegate")
+	}
+	if c.builder && (c.style != "mock" || c.onlyMissing || c.delegate) {
+		return c.failUsage("-builder requires -style mock and cannot be used with -missing or -delegate")
+	}
+	if c.style == "stub" && c.packageOut != "" {
+		return c.failUsage("-style stub cannot be used with -package-out")
+	}
+	// Stubs and -no-gen-header output are edited, so they aren't marked
+	// as generated, nor regenerated by go generate, unless asked to.
+	if c.style == "stub" || c.noGenHeader {
+		set := make(map[string]bool)
+		c.flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if !set["header"] || c.noGenHeader {
+			c.header = ""
+		}
+		if !set["embed-directive"] {
+			c.embedDirective = false
+		}
+	}
+	if c.zeroHelper && (c.style != "mock" || c.onlyMissing) {
+		return c.failUsage("-zero-helper requires -style mock and cannot be used with -missing")
+	}
+	if c.logCalls && (c.style != "mock" || c.onlyMissing) {
+		return c.failUsage("-log requires -style mock and cannot be used with -missing")
+	}
+	if c.syncMode != "" && (c.style != "mock" || !(c.capture || c.spy || c.queue)) {
+		return c.failUsage("-sync requires -style mock and -capture, -spy or -queue")
+	}
+	if len(c.returnVals) > 0 && (c.style != "mock" || c.strict || c.embedIface) {
+		return c.failUsage("-return requires -style mock and cannot be used with -strict or -embed-iface")
+	}
+	if selecting && (c.split || c.embedIface || c.delegate) {
+		return c.failUsage("-only, -skip, -match and -skip-match cannot be used with -split, -embed-iface or -delegate")
+	}
+	if multi && (c.onlyMissing || c.appendMode || c.packageOut != "" || c.jsonOut || c.list || selecting) {
+		return c.failUsage("several interfaces with -name cannot be used with -missing, -append, -package-out, -json, -list, -only, -skip, -match or -skip-match")
+	}
+	if c.expectClose && !c.capture && !c.spy {
+		return c.failUsage("-expect-close requires -capture or -spy")
+	}
+	if c.asserts && !c.capture && !c.spy {
+		return c.failUsage("-asserts requires -capture or -spy")
+	}
+	for _, text := range []string{c.comment, c.structComment} {
+		if _, err := testgen.RenderComment(text, "Name", iface, recvType); err != nil {
+			return c.fail(err)
+		}
+	}
+	if c.watchMode {
+		if (out == "" && c.packageOut == "") || c.diffOnly || c.jsonOut || c.list || strings.HasPrefix(iface, "interface") {
+			return c.failUsage("-watch requires an output file and a named interface, and cannot be used with -diff, -json or -list")
+		}
+		// The output may be in a watched package, e.g. next to the
+		// interface, and writing it must not trigger another run.
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return c.fail(err)
+		}
+		output := func(file string) bool {
+			if !c.split {
+				return file == abs
+			}
+			dir, base := filepath.Split(file)
+			return filepath.Clean(dir) == abs && (base == stem+".go" || strings.HasPrefix(base, stem+"_"))
+		}
+		return c.fail(c.watch(iface, withoutWatch(cmdline), output))
+	}
+	cfg := testgen.Config{RecvName: c.recvName, PointerZero: c.pointerZero, Strict: c.strict, Style: c.style, Imports: c.pinned, EmbedIface: c.embedIface, Defaults: defs, Capture: c.capture || c.spy, Asserts: c.asserts,
+		Comment: c.comment, StructComment: c.structComment, Generic: c.generic(iface), TypeParams: c.typeParams(iface), Concrete: c.concrete, Builder: c.builder, Queue: c.queue, Spy: c.spy, ExpectClose: c.expectClose, Raw: c.raw, NoFormat: c.noFormat, SmartDefaults: c.smartDefaults, LintSuppress: c.lintSuppress, Returns: c.returnVals, Sync: c.syncMode, Log: c.logCalls, ZeroHelper: c.zeroHelper,
+		Declares: c.declares, Warnf: c.warnf}
+
+	var tmpl string
+	switch c.style {
+	case "mock":
+		tmpl = testgen.TypeTmpl
+		if c.delegate {
+			tmpl = testgen.DelegateTmpl
+		}
+	case "testify":
+		tmpl = testgen.TestifyTmpl
+	case "gomock":
+		if c.generic(iface) {
+			return c.failUsage(fmt.Sprintf("-style gomock does not support generic interfaces: %s", iface))
+		}
+		tmpl = testgen.GomockTmpl
+	case "stub":
+		tmpl = testgen.StubTmpl
+	default:
+		return c.failUsage(fmt.Sprintf("invalid -style: %s", c.style))
+	}
+
+	if specMode {
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return c.fail(err)
+		}
+		pkg := dirPackage(abs)
+		if gopkg := os.Getenv("GOPACKAGE"); gopkg != "" {
+			pkg = gopkg
+		}
+		if c.pkgName != "" {
+			pkg = c.pkgName
+		}
+		cfg.PkgPath, _ = dirImportPath(abs)
+		files := make(map[string][]byte)
+		var names []string
+		for i, recv := range recvs {
+			// An interface of -pkg-all that cannot be implemented in pkg,
+			// e.g. a sealed one, is skipped rather than failing the others.
+			if i >= pkgAllFrom {
+				id, ifacePkg, _, fns, err := c.funcs(specIfaces[i])
+				if err != nil {
+					return c.fail(err)
+				}
+				if err := checkAccess(ifacePkg+"."+id, ifacePkg, pkg, fns); err != nil {
+					c.logf("skipping %s: %v", specIfaces[i], err)
+					continue
+				}
+			}
+			name := "mock_" + strings.ToLower(recv) + ".go"
+			if _, ok := files[name]; ok {
+				return c.failUsage(fmt.Sprintf("-spec writes %s twice", name))
+			}
+			hdr, err := renderHeader(c.header, specIfaces[i], recv)
+			if err != nil {
+				return c.fail(err)
+			}
+			// Each file can be regenerated on its own.
+			if c.embedDirective && os.Getenv("GOFILE") == "" {
+				hdr += "\n" + c.directive(recv, specIfaces[i], out, name) + "\n"
+			}
+			mcfg := cfg
+			mcfg.Header, mcfg.Filename = hdr, filepath.Join(out, name)
+			src, err := c.multiMock(tmpl, specIfaces[i:i+1], recvs[i:i+1], pkg, mcfg)
+			if err != nil {
+				return c.fail(fmt.Errorf("%s=%s: %w", recv, specIfaces[i], err))
+			}
+			files[name] = src
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			return c.fail(classed(fmt.Errorf("no mockable interfaces found in %s", c.pkgAll), errNotFound))
+		}
+		if c.checkCompile {
+			if err := compileCheck(out, files); err != nil {
+				return c.fail(err)
+			}
+		}
+		differs := false
+		for _, name := range names {
+			d, err := c.writeFile(filepath.Join(out, name), files[name])
+			if err != nil {
+				return c.fail(err)
+			}
+			differs = differs || d
+		}
+		if differs {
+			return exitFailure
+		}
+		return 0
+	}
+
+	if c.split {
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return c.fail(err)
+		}
+		pkg := dirPackage(abs)
+		if gopkg := os.Getenv("GOPACKAGE"); gopkg != "" {
+			pkg = gopkg
+		}
+		if c.pkgName != "" {
+			pkg = c.pkgName
+		}
+		if err := checkRecvPkg(pkg); err != nil {
+			return c.fail(err)
+		}
+		if cfg.Header, err = renderHeader(c.header, iface, recvType); err != nil {
+			return c.fail(err)
+		}
+		base := strings.ToLower(recvType) + ".go"
+		if gofile := os.Getenv("GOFILE"); c.embedDirective && (gofile == "" || gofile == base) {
+			cfg.Header += "\n" + c.directive(recvType, iface, out, ".") + "\n"
+		}
+		cfg.PkgPath, _ = dirImportPath(abs)
+		files, err := c.splitFiles(recvType, strings.Split(iface, ","), pkg, out, cfg)
+		if err != nil {
+			return c.fail(err)
+		}
+		if c.checkCompile {
+			if err := compileCheck(out, files); err != nil {
+				return c.fail(err)
+			}
+		}
+		var names []string
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		differs := false
+		for _, name := range names {
+			d, err := c.writeFile(filepath.Join(out, name), files[name])
+			if err != nil {
+				return c.fail(err)
+			}
+			differs = differs || d
+		}
+		if differs {
+			return exitFailure
+		}
+		return 0
+	}
+
+	if multi {
+		// Output to stdout goes into the package of the first interface.
+		var pkg string
+		if out != "" {
+			abs, err := filepath.Abs(filepath.Dir(out))
+			if err != nil {
+				return c.fail(err)
+			}
+			pkg = dirPackage(abs)
+			cfg.PkgPath, _ = dirImportPath(abs)
+		}
+		if gopkg := os.Getenv("GOPACKAGE"); gopkg != "" {
+			pkg = gopkg
+		}
+		if c.pkgName != "" {
+			pkg = c.pkgName
+		}
+		hdr, err := renderHeader(c.header, iface, strings.Join(recvs, ","))
+		if err != nil {
+			return c.fail(err)
+		}
+		if gofile := os.Getenv("GOFILE"); c.embedDirective && out != "" && (gofile == "" || gofile == filepath.Base(out)) {
+			hdr += "\n" + c.directive("", iface, filepath.Dir(out), filepath.Base(out)) + "\n"
+		}
+		cfg.Header, cfg.Filename = hdr, out
+		src, err := c.multiMock(tmpl, strings.Split(iface, ","), recvs, pkg, cfg)
+		if err != nil {
+			return c.fail(err)
+		}
+		if c.checkCompile {
+			dir, name := filepath.Dir(out), filepath.Base(out)
+			if out == "" {
+				dir, name = ".", "mocks.go"
+			}
+			if err := compileCheck(dir, map[string][]byte{name: src}); err != nil {
+				return c.fail(err)
+			}
+		}
+		if out == "" {
+			fmt.Fprint(c.stdout, string(src))
+			return 0
+		}
+		differs, err := c.writeFile(out, src)
+		if err != nil {
+			return c.fail(err)
+		}
+		if differs {
+			return exitFailure
+		}
+		return 0
+	}
+
+	ifaceName, pkg, ifacePath, fns, err := c.funcs(iface)
+	if err != nil {
+		return c.fail(err)
+	}
+	if selecting {
+		if fns, err = selectFuncs(iface, fns, c.only, c.skip, matchRx, skipMatchRx); err != nil {
+			return c.fail(err)
+		}
+		cfg.Partial = true
+	}
+	if err := checkReturns(iface, fns, c.returnVals); err != nil {
+		return c.fail(err)
+	}
+	if c.jsonOut {
+		b, err := json.MarshalIndent(ifaceJSON{Name: ifaceName, Package: pkg, Path: ifacePath, Methods: fns}, "", "\t")
+		if err != nil {
+			return c.fail(err)
+		}
+		fmt.Fprintln(c.stdout, string(b))
+		return 0
+	}
+	if c.list {
+		for _, fn := range fns {
+			fmt.Fprintln(c.stdout, fn)
+		}
+		return 0
+	}
+	// A standalone mock package is named after the interface.
+	if c.packageOut != "" {
+		out = filepath.Join(c.packageOut, strings.ToLower(ifaceName)+"mock", "mock_"+strings.ToLower(recvType)+".go")
+	}
+	ifacePkg := pkg
+	if pkg != "" {
+		ifaceName = pkg + "." + ifaceName
+	} else {
+		// An interface literal is generated into the current package.
+		pkg = dirPackage(c.importDir)
+	}
+
+	if out != "" {
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return c.fail(err)
+		}
+		pkg = dirPackage(filepath.Dir(abs))
+	}
+	if gopkg := os.Getenv("GOPACKAGE"); gopkg != "" && c.packageOut == "" {
+		pkg = gopkg
+	}
+
+	if c.onlyMissing {
+		dir := "."
+		if out != "" {
+			dir = filepath.Dir(out)
+		}
+		existing, err := c.methods(dir, recvType, out)
+		if err != nil {
+			return c.fail(err)
+		}
+		if fns, err = missing(recvType, fns, existing); err != nil {
+			return c.fail(err)
+		}
+		if out == "" && os.Getenv("GOPACKAGE") == "" {
+			if p, err := build.ImportDir(dir, 0); err == nil {
+				pkg = p.Name
+			}
+		}
+		if c.style != "stub" {
+			tmpl = testgen.MissingTmpl
+		}
+	}
+	// An existing mock gets only the methods it lacks.
+	var old []byte
+	if c.appendMode {
+		if old, err = ioutil.ReadFile(out); err != nil && !os.IsNotExist(err) {
+			return c.fail(err)
+		}
+		if old != nil {
+			existing, err := c.methods(filepath.Dir(out), recvType, "")
+			if err != nil {
+				return c.fail(err)
+			}
+			if fns, err = missing(recvType, fns, existing); err != nil {
+				return c.fail(err)
+			}
+		}
+	}
+	if c.pkgName != "" {
+		pkg = c.pkgName
+	}
+	// Output to stdout goes into the package of a qualified receiver.
+	if recvPkg != "" && out == "" && c.pkgName == "" && os.Getenv("GOPACKAGE") == "" && !c.onlyMissing {
+		pkg = recvPkg
+	}
+	if err := checkRecvPkg(pkg); err != nil {
+		return c.fail(err)
+	}
+	if c.expectClose && !hasClose(fns) {
+		return c.fail(classed(fmt.Errorf("-expect-close requires %s to have a Close method without params", ifaceName), errUsage))
+	}
+	if c.builder {
+		if err := checkBuilder(fns); err != nil {
+			return c.fail(err)
+		}
+	}
+	if err := checkAccess(ifaceName, ifacePkg, pkg, fns); err != nil {
+		return c.fail(err)
+	}
+
+	hdr, err := renderHeader(c.header, ifaceName, recvType)
+	if err != nil {
+		return c.fail(err)
+	}
+	docHdr := hdr
+	// Under go generate the source file already has a directive,
+	// unless it is the generated file itself.
+	var dir string
+	if gofile := os.Getenv("GOFILE"); c.embedDirective && out != "" && (gofile == "" || gofile == filepath.Base(out)) {
+		dir = c.directive(recvType, iface, filepath.Dir(out), filepath.Base(out))
+		// keep the directive out of the package doc
+		hdr += "\n" + dir + "\n"
+	}
+
+	cfg.Header, cfg.Filename = hdr, out
+	// Types of the package generated into are not qualified. Output to
+	// stdout goes into the package of the interface, unless told otherwise.
+	switch {
+	case out != "" || c.onlyMissing:
+		if abs, err := filepath.Abs(filepath.Dir(out)); err == nil {
+			cfg.PkgPath, _ = dirImportPath(abs)
+		}
+	case pkg == ifacePkg:
+		cfg.PkgPath = ifacePath
+	}
+	src, err := testgen.Generate(tmpl, ifaceName, ifacePath, pkg, recvType, fns, cfg)
+	if err != nil {
+		return c.fail(err)
+	}
+	if old != nil && len(fns) == 0 {
+		src = old
+	} else if old != nil {
+		if src, err = appendMock(old, src, recvType, dir, cfg); err != nil {
+			return c.fail(err)
+		}
+	}
+
+	var doc []byte
+	if c.packageOut != "" {
+		if doc, err = genDoc(ifaceName, pkg, recvType, testgen.Config{Header: docHdr, Style: c.style, Generic: cfg.Generic}); err != nil {
+			return c.fail(err)
+		}
+	}
+	if c.checkCompile {
+		// Output to stdout is checked as a file of the package it
+		// goes into.
+		dir, name := filepath.Dir(out), filepath.Base(out)
+		if out == "" {
+			dir, name = ".", "mock_"+strings.ToLower(recvType)+".go"
+			if p, err := build.Import(ifacePath, c.importDir, build.FindOnly); err == nil && ifacePath != "" && cfg.PkgPath == ifacePath {
+				dir = p.Dir
+			}
+		}
+		srcs := map[string][]byte{name: src}
+		if doc != nil {
+			srcs["doc.go"] = doc
+		}
+		if err := compileCheck(dir, srcs); err != nil {
+			return c.fail(err)
+		}
+	}
+
+	// write sources
+	if out == "" {
+		fmt.Fprint(c.stdout, string(src))
+		return 0
+	}
+
+	differs, err := c.writeFile(out, src)
+	if err != nil {
+		return c.fail(err)
+	}
+	if doc != nil {
+		d, err := c.writeFile(filepath.Join(filepath.Dir(out), "doc.go"), doc)
+		if err != nil {
+			return c.fail(err)
+		}
+		differs = differs || d
+	}
+	if differs {
+		return exitFailure
+	}
+	return 0
+}
 
-	fmt.Printf("generated file: %s\n", out)
+// fail prints err to stderr and returns the exit code of its class.
+func (c *command) fail(err error) int {
+	fmt.Fprintln(c.stderr, err)
+	return exitCode(err)
 }
 
-func fatal(msg interface{}) {
-	fmt.Fprintln(os.Stderr, msg)
-	os.Exit(1)
+// failUsage prints msg, a misuse of the flags, to stderr and returns
+// exitUsage.
+func (c *command) failUsage(msg string) int {
+	return c.fail(classed(errors.New(msg), errUsage))
 }
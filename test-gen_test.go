@@ -0,0 +1,3601 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+
+	"test-gen/testgen"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// testgenBin is the binary built by TestMain.
+var testgenBin string
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "testgen")
+	if err != nil {
+		panic(err)
+	}
+	testgenBin = filepath.Join(dir, "testgen")
+	if out, err := exec.Command("go", "build", "-o", testgenBin, ".").CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		panic(string(out))
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// sandbox is a GOPATH in a temporary directory, holding a copy of the
+// fixtures of testdata/src and the package out that mocks are generated
+// into.
+type sandbox struct {
+	t    testing.TB
+	root string
+	dir  string // of the package out
+}
+
+func newSandbox(t testing.TB) *sandbox {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "src", "out")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := filepath.Walk(filepath.Join("testdata", "src"), func(path string, fi os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(root, "src", strings.TrimPrefix(path, filepath.Join("testdata", "src")))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		return ioutil.WriteFile(dst, src, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sandbox{t: t, root: root, dir: dir}
+}
+
+// env returns the environment of commands run in the sandbox, which have
+// testgen in their PATH, for go generate.
+func (g *sandbox) env() []string {
+	path := filepath.Dir(testgenBin) + string(filepath.ListSeparator) + os.Getenv("PATH")
+	return append(os.Environ(), "GO111MODULE=off", "GOFLAGS=", "GOPATH="+g.root, "PATH="+path)
+}
+
+// run runs testgen with args in the package out, and returns its stdout,
+// stderr and exit code.
+func (g *sandbox) run(args ...string) (stdout, stderr string, code int) {
+	g.t.Helper()
+	cmd := exec.Command(testgenBin, args...)
+	cmd.Dir, cmd.Env = g.dir, g.env()
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+	if exit, ok := err.(*exec.ExitError); ok {
+		code = exit.ExitCode()
+	} else if err != nil {
+		g.t.Fatal(err)
+	}
+	return out.String(), errOut.String(), code
+}
+
+// gen runs testgen with args, writing to the file of the package out,
+// fails unless it succeeds, and returns the file.
+func (g *sandbox) gen(file string, args ...string) string {
+	g.t.Helper()
+	if _, stderr, code := g.run(append(args, file)...); code != 0 {
+		g.t.Fatalf("testgen %s: exit %d\n%s", strings.Join(args, " "), code, stderr)
+	}
+	return g.read(file)
+}
+
+// read returns the file of the package out.
+func (g *sandbox) read(file string) string {
+	g.t.Helper()
+	src, err := ioutil.ReadFile(filepath.Join(g.dir, file))
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	return string(src)
+}
+
+// write writes the file of the package out, creating its directory.
+func (g *sandbox) write(file, src string) {
+	g.t.Helper()
+	path := filepath.Join(g.dir, file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		g.t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		g.t.Fatal(err)
+	}
+}
+
+// goCmd runs the go command with args in the package out, failing unless
+// it succeeds.
+func (g *sandbox) goCmd(args ...string) {
+	g.t.Helper()
+	cmd := exec.Command("go", args...)
+	cmd.Dir, cmd.Env = g.dir, g.env()
+	if out, err := cmd.CombinedOutput(); err != nil {
+		g.t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// vet fails unless the package out compiles and passes go vet.
+func (g *sandbox) vet() {
+	g.t.Helper()
+	g.goCmd("vet", ".")
+}
+
+// contains fails unless src contains each of want.
+func contains(t *testing.T, src string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(src, w) {
+			t.Errorf("missing %q in\n%s", w, src)
+		}
+	}
+}
+
+// golden compares got with testdata/golden/name.golden, which -update
+// rewrites.
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from %s:\n%s", name, path, unifiedDiff(path, name, want, []byte(got)))
+	}
+}
+
+func TestRecvName(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "func (t *Mock) Read(p []byte) (n int, err error) {"},
+		{[]string{"-rname", "m"}, "func (m *Mock) Read(p []byte) (n int, err error) {"},
+	} {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			g := newSandbox(t)
+			contains(t, g.gen("mock.go", append(tc.args, "Mock", "io.Reader")...), tc.want)
+			g.vet()
+		})
+	}
+}
+
+func TestSelfReturn(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-rname", "m", "Mock", "fixture/clone.Iface"), "return m\n")
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestClone(t *testing.T) {
+	m := &Mock{}
+	if c := m.Clone(); c != m {
+		t.Errorf("Clone() = %v, want the mock", c)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestZeroResults(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-pointer-zero", "alloc", "Mock", "fixture/zero.Results"), "return &bytes.Buffer{}\n", "\treturn nil\n}\n\n// Writer ...")
+	g.vet()
+}
+
+func TestPointerZero(t *testing.T) {
+	for _, tc := range []struct{ mode, cmp string }{
+		{"nil", "!="},
+		{"alloc", "=="},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			g := newSandbox(t)
+			g.gen("mock.go", "-pointer-zero", tc.mode, "Mock", "fixture/list.List")
+			g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestNext(t *testing.T) {
+	if n := (&Mock{}).Next(); n `+tc.cmp+` nil {
+		t.Errorf("Next() = %v with -pointer-zero `+tc.mode+`", n)
+	}
+}
+`)
+			g.goCmd("test", ".")
+		})
+	}
+	g := newSandbox(t)
+	if _, stderr, code := g.run("-pointer-zero", "new", "Mock", "fixture/list.List"); code == 0 || !strings.Contains(stderr, "invalid -pointer-zero: new") {
+		t.Errorf("exit %d, stderr %q, want an invalid -pointer-zero error", code, stderr)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	g := newSandbox(t)
+	diff := func(wantCode int) string {
+		t.Helper()
+		stdout, stderr, code := g.run("-diff", "Mock", "io.Reader", "mock.go")
+		if code != wantCode {
+			t.Fatalf("exit %d, want %d\n%s", code, wantCode, stderr)
+		}
+		return stdout
+	}
+	// A missing file is all added, and not written.
+	contains(t, diff(1), "+++ ", "+package out\n")
+	if _, err := os.Stat(filepath.Join(g.dir, "mock.go")); !os.IsNotExist(err) {
+		t.Errorf("-diff wrote mock.go: %v", err)
+	}
+	src := g.gen("mock.go", "Mock", "io.Reader")
+	if d := diff(0); d != "" {
+		t.Errorf("diff of an up to date file:\n%s", d)
+	}
+	g.write("mock.go", strings.Replace(src, "package out", "package out // edited", 1))
+	contains(t, diff(1), "-package out // edited\n+package out\n")
+	if g.read("mock.go") == src {
+		t.Error("-diff overwrote the edited file")
+	}
+}
+
+func TestMissing(t *testing.T) {
+	g := newSandbox(t)
+	// Types of other packages in existing methods are looked up too.
+	g.write("file.go", "package out\n\nimport \"io\"\n\ntype File struct{}\n\nfunc (*File) Read(p []byte) (int, error) { return 0, nil }\n\nfunc (*File) ReadFrom(r io.Reader) (int64, error) { return 0, nil }\n")
+	src := g.gen("missing.go", "-missing", "File", "io.ReadWriteCloser")
+	contains(t, src, "func (t *File) Write(p []byte) (n int, err error) {", "func (t *File) Close() error {")
+	if strings.Contains(src, "Read(") || strings.Contains(src, "struct") {
+		t.Errorf("want only Write and Close in\n%s", src)
+	}
+	g.vet()
+
+	g.write("file.go", "package out\n\ntype File struct{}\n\nfunc (*File) Read(p []byte) int { return 0 }\n")
+	_, stderr, code := g.run("-missing", "File", "io.ReadWriteCloser", "missing.go")
+	if want := "method File.Read has a different signature than the interface"; code == 0 || !strings.Contains(stderr, want) {
+		t.Errorf("exit %d, stderr %q, want an error containing %q", code, stderr, want)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	g := newSandbox(t)
+	src := g.gen("mock.go", "Mock", "io.Reader")
+	if want := "// Code generated by testgen; DO NOT EDIT.\n"; !strings.HasPrefix(src, want) {
+		t.Errorf("got\n%s\nwant it to start with %s", src, want)
+	}
+	src = g.gen("mock.go", "-header", "// Code generated by testgen {{.Version}} from {{.Iface}} for {{.Recv}}; DO NOT EDIT.", "Mock", "io.Reader")
+	if want := "// Code generated by testgen devel from io.Reader for Mock; DO NOT EDIT.\n"; !strings.HasPrefix(src, want) {
+		t.Errorf("got\n%s\nwant it to start with %s", src, want)
+	}
+	g.vet()
+	if _, stderr, code := g.run("-header", "{{.Bogus}}", "Mock", "io.Reader"); code == 0 || !strings.Contains(stderr, "invalid header") {
+		t.Errorf("exit %d, stderr %q, want an invalid header error", code, stderr)
+	}
+}
+
+func TestGoGenerate(t *testing.T) {
+	g := newSandbox(t)
+	g.write("gen.go", `package mocks
+
+//go:generate testgen -recv Mock -iface io.Reader
+//go:generate testgen -recv Other -iface io.Writer -o other.go
+`)
+	g.goCmd("generate")
+	contains(t, g.read("gen_mock.go"), "package mocks\n", "type Mock struct {")
+	contains(t, g.read("other.go"), "package mocks\n", "type Other struct {")
+	g.vet()
+
+	stdout, stderr, code := g.run("-pkg", "fakes", "-recv", "Mock", "-iface", "io.Reader")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, stdout, "package fakes\n")
+}
+
+func TestDottedPath(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "gopkg.in/yaml.v3.Marshaler"),
+		"-iface gopkg.in/yaml.v3.Marshaler ", "func (t *Mock) MarshalYAML() (interface{}, error) {")
+	g.vet()
+}
+
+func TestJSON(t *testing.T) {
+	g := newSandbox(t)
+	stdout, stderr, code := g.run("-json", "Mock", "fixture/logger.Logger")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	var got ifaceJSON
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("%v in\n%s", err, stdout)
+	}
+	want := ifaceJSON{Name: "Logger", Package: "logger", Path: "fixture/logger", Methods: []testgen.Func{{
+		Name: "Logf",
+		Params: []testgen.Param{
+			{Name: "format", Type: "string"},
+			{Name: "args", Type: "...interface{}", Variadic: true},
+		},
+		Res: []testgen.Param{{Name: "n", Type: "int"}, {Name: "err", Type: "error"}},
+	}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Interface literals have no import path.
+	stdout, stderr, code = g.run("-json", "Mock", "interface{ Close() error }")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	if strings.Contains(stdout, `"path"`) {
+		t.Errorf("path of an interface literal in\n%s", stdout)
+	}
+}
+
+func TestSameNamedPackages(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/merge.Merger"),
+		`"fixture/a/config"`, `config2 "fixture/b/config"`,
+		"Get() config.Options {", "Set(o *config2.Options) error {")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+
+	aconfig "fixture/a/config"
+	bconfig "fixture/b/config"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	var set *bconfig.Options
+	m.SetFunc = func(o *bconfig.Options) error { set = o; return nil }
+	m.Set(&bconfig.Options{B: 1})
+	if set == nil || set.B != 1 {
+		t.Errorf("SetFunc got %v", set)
+	}
+	if got := m.Get(); got != (aconfig.Options{}) {
+		t.Errorf("Get() = %v", got)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestDoc(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/doc.Store"),
+		"\t// Get returns the value of key.\n\t//\n\t// It returns false if key is not set.\n\tGetFunc func(",
+		"// Get returns the value of key.\n//\n// It returns false if key is not set.\nfunc (t *Mock) Get(",
+		"// Put sets key to value.\nfunc (t *Mock) Put(",
+		"// Len ...\nfunc (t *Mock) Len(")
+	g.vet()
+}
+
+func TestStrict(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-strict", "Mock", "sort.Interface"), `panic("Mock.Swap: not implemented")`)
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestStrict(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "Mock.Swap: not implemented" {
+			t.Errorf("recovered %v", r)
+		}
+	}()
+	m := &Mock{LenFunc: func() int { return 2 }}
+	if n := m.Len(); n != 2 {
+		t.Errorf("Len() = %d", n)
+	}
+	m.Swap(0, 1)
+	t.Error("Swap did not panic")
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestUnexportedType(t *testing.T) {
+	g := newSandbox(t)
+	_, stderr, code := g.run("Mock", "fixture/hidden.Handler", "mock.go")
+	want := "method Handle of hidden.Handler uses unexported type hidden.request; generate into package hidden (e.g. -pkg hidden -o <file in hidden>) or export the type"
+	if code == 0 || !strings.Contains(stderr, want) {
+		t.Errorf("exit %d, stderr %q, want an error containing %q", code, stderr, want)
+	}
+	// Generating into the package itself works.
+	if _, stderr, code := g.run("-pkg", "hidden", "Mock", "fixture/hidden.Handler", "../fixture/hidden/mock.go"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	g.goCmd("vet", "fixture/hidden")
+}
+
+func TestStd(t *testing.T) {
+	for _, tc := range []struct{ iface, test string }{
+		{"io.ReadWriteCloser", `
+import (
+	"io"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var rwc io.ReadWriteCloser = &Mock{WriteFunc: func(p []byte) (int, error) { return len(p), nil }}
+	if n, err := rwc.Write([]byte("abc")); n != 3 || err != nil {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+	if n, err := rwc.Read(nil); n != 0 || err != nil {
+		t.Errorf("Read() = %d, %v", n, err)
+	}
+}
+`},
+		{"http.Handler", `
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var served bool
+	var h http.Handler = &Mock{ServeHTTPFunc: func(w http.ResponseWriter, r *http.Request) { served = true }}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !served {
+		t.Error("ServeHTTPFunc not called")
+	}
+	(&Mock{}).ServeHTTP(nil, nil)
+}
+`},
+		{"sort.Interface", `
+import (
+	"sort"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	s := []int{3, 1, 2}
+	sort.Sort(&Mock{
+		LenFunc:  func() int { return len(s) },
+		LessFunc: func(i, j int) bool { return s[i] < s[j] },
+		SwapFunc: func(i, j int) { s[i], s[j] = s[j], s[i] },
+	})
+	if s[0] != 1 || s[1] != 2 || s[2] != 3 {
+		t.Errorf("sorted %v", s)
+	}
+}
+`},
+	} {
+		t.Run(tc.iface, func(t *testing.T) {
+			g := newSandbox(t)
+			g.gen("mock.go", "Mock", tc.iface)
+			g.write("mock_test.go", "package out\n"+tc.test)
+			g.goCmd("test", ".")
+		})
+	}
+
+	// Same-named packages are told apart by the type, and it is an error
+	// if several declare it.
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "rand.Source"), "\t\"math/rand\"\n", "var _ rand.Source = (*Mock)(nil)")
+	g.vet()
+	_, stderr, code := g.run("Mock", "template.FuncMap")
+	if want := "template.FuncMap is ambiguous, it is declared by html/template and text/template; use its import path, e.g. html/template.FuncMap"; code != exitUsage || !strings.Contains(stderr, want) {
+		t.Errorf("exit %d, stderr %q, want exit %d and %q", code, stderr, exitUsage, want)
+	}
+	contains(t, g.gen("mock.go", "-concrete", "Mock", "text/template.Template"), "\t\"text/template\"\n")
+}
+
+func TestForce(t *testing.T) {
+	g := newSandbox(t)
+	hand := "package out\n\n// Mock is written by hand.\ntype Mock struct{}\n"
+	g.write("mock.go", hand)
+	_, stderr, code := g.run("Mock", "io.Reader", "mock.go")
+	if want := "not a generated file (use -force to overwrite)"; code == 0 || !strings.Contains(stderr, want) {
+		t.Errorf("exit %d, stderr %q, want an error containing %q", code, stderr, want)
+	}
+	if got := g.read("mock.go"); got != hand {
+		t.Errorf("mock.go was overwritten:\n%s", got)
+	}
+	contains(t, g.gen("mock.go", "-force", "Mock", "io.Reader"), "func (t *Mock) Read(")
+	// A generated file is overwritten without -force.
+	contains(t, g.gen("mock.go", "Mock", "io.Writer"), "func (t *Mock) Write(")
+	g.vet()
+}
+
+func TestTestify(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "testify", g.gen("mock.go", "-style", "testify", "Mock", "fixture/kv.Store"))
+	g.write("mock_test.go", `package out
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	m.On("Get", "a").Return("b", nil)
+	m.On("Put", "a", []byte("b")).Return(errors.New("full"))
+	m.On("Each", "p", mock.Anything, mock.Anything).Return(2, nil)
+	m.On("Open", "a").Return(nil, true, nil)
+	m.On("Reset").Return()
+
+	if v, err := m.Get("a"); v != "b" || err != nil {
+		t.Errorf("Get() = %q, %v", v, err)
+	}
+	if err := m.Put("a", []byte("b")); err == nil || err.Error() != "full" {
+		t.Errorf("Put() = %v", err)
+	}
+	f := func(string) bool { return true }
+	if n, err := m.Each("p", f, f); n != 2 || err != nil {
+		t.Errorf("Each() = %d, %v", n, err)
+	}
+	if r, ok, err := m.Open("a"); r != nil || !ok || err != nil {
+		t.Errorf("Open() = %v, %v, %v", r, ok, err)
+	}
+	m.Reset()
+	m.AssertExpectations(t)
+	m.AssertCalled(t, "Each", "p", mock.Anything, mock.Anything)
+}
+`)
+	g.goCmd("test", ".")
+}
+
+// TestTestifyLocals runs a testify mock of an interface whose params are
+// named like the locals of the generated methods.
+func TestTestifyLocals(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-style", "testify", "Mock", "fixture/store.Store"),
+		"func (t *Mock) Get(ret int) (string, error) {",
+		"func (t *Mock) Open(mr string, varargs string, arg2 int) (io.ReadCloser, bool, error) {")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	m.On("Get", 1).Return("one", nil)
+	m.On("Each", "r", mock.Anything).Return(1, nil)
+	m.On("Open", "a", "b", 3).Return(nil, true, nil)
+
+	if v, err := m.Get(1); v != "one" || err != nil {
+		t.Errorf("Get() = %q, %v", v, err)
+	}
+	if n, err := m.Each("r", func(string) bool { return false }); n != 1 || err != nil {
+		t.Errorf("Each() = %d, %v", n, err)
+	}
+	if r, ok, err := m.Open("a", "b", 3); r != nil || !ok || err != nil {
+		t.Errorf("Open() = %v, %v, %v", r, ok, err)
+	}
+	m.AssertExpectations(t)
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestGomock(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "gomock", g.gen("mock.go", "-style", "gomock", "MockReader", "io.Reader"))
+	g.gen("store.go", "-style", "gomock", "MockStore", "fixture/kv.Store")
+	g.write("mock_test.go", `package out
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestMock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := NewMockReader(ctrl)
+	r.EXPECT().Read(gomock.Any()).Return(3, nil)
+	if n, err := r.Read(make([]byte, 3)); n != 3 || err != nil {
+		t.Errorf("Read() = %d, %v", n, err)
+	}
+
+	s := NewMockStore(ctrl)
+	f := func(string) bool { return true }
+	s.EXPECT().Each("p", gomock.Any(), gomock.Any()).Return(2, nil)
+	s.EXPECT().Open("a").Return(nil, true, errors.New("locked"))
+	s.EXPECT().Reset()
+	if n, err := s.Each("p", f, f); n != 2 || err != nil {
+		t.Errorf("Each() = %d, %v", n, err)
+	}
+	if rc, ok, err := s.Open("a"); rc != nil || !ok || err == nil {
+		t.Errorf("Open() = %v, %v, %v", rc, ok, err)
+	}
+	s.Reset()
+}
+`)
+	g.goCmd("test", ".")
+
+	// Params named like the locals of the generated methods and the
+	// packages they refer to are renamed.
+	contains(t, g.gen("locals.go", "-style", "gomock", "MockLocals", "fixture/store.Store"),
+		"func (t *MockLocals) Open(mr string, varargs string, arg2 int) (io.ReadCloser, bool, error) {",
+		"func (t *MockLocals) Check(arg0 bool, arg1 ...string) error {")
+	g.write("locals_test.go", `package out
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestLocals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := NewMockLocals(ctrl)
+	s.EXPECT().Open("a", "b", 3).Return(nil, false, errors.New("gone"))
+	s.EXPECT().Check(true, "x", "y").Return(nil)
+	if _, _, err := s.Open("a", "b", 3); err == nil {
+		t.Error("Open() = nil error")
+	}
+	if err := s.Check(true, "x", "y"); err != nil {
+		t.Errorf("Check() = %v", err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	_, stderr, code := g.run("-style", "gomock", "Mock", "fixture/generic.Getter")
+	if want := "-style gomock does not support generic interfaces: fixture/generic.Getter"; code == 0 || !strings.Contains(stderr, want) {
+		t.Errorf("exit %d, stderr %q, want an error containing %q", code, stderr, want)
+	}
+}
+
+func TestImportFlag(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-import", "rand=math/rand", "Mock", "rand.Source"), "func (t *Mock) Int63() int64 {")
+	g.write("mock_test.go", `package out
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	r := rand.New(&Mock{Int63Func: func() int64 { return 42 }})
+	if n := r.Int63(); n != 42 {
+		t.Errorf("Int63() = %d", n)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	for _, tc := range []struct{ pin, want string }{
+		{"rand=crypto/rand", "type Source not found in crypto/rand"},
+		{"rand=nope/rand", `invalid value "rand=nope/rand" for flag -import: couldn't find package nope/rand`},
+		{"math/rand", "expected name=path: math/rand"},
+	} {
+		if _, stderr, code := g.run("-import", tc.pin, "Mock", "rand.Source"); code == 0 || !strings.Contains(stderr, tc.want) {
+			t.Errorf("-import %s: exit %d, stderr %q, want an error containing %q", tc.pin, code, stderr, tc.want)
+		}
+	}
+}
+
+func TestEmbedDirective(t *testing.T) {
+	g := newSandbox(t)
+	src := g.gen("mock.go", "-strict", "-import", "rand=math/rand", "Mock", "rand.Source")
+	contains(t, src, "\n//go:generate testgen -import rand=math/rand -strict=true -recv Mock -iface math/rand.Source -o mock.go\n")
+	// go generate regenerates the file with the flags of its directive.
+	g.write("mock.go", strings.Replace(src, "-strict=true", "-strict=true -rname=m", 1))
+	g.goCmd("generate")
+	regen := g.read("mock.go")
+	contains(t, regen, "-rname=m -strict=true -recv", "func (m *Mock) Int63() int64 {", `panic("Mock.Int63: not implemented")`)
+	g.goCmd("generate")
+	if again := g.read("mock.go"); again != regen {
+		t.Errorf("go generate changed mock.go:\n%s", unifiedDiff("mock.go", "regenerated", []byte(regen), []byte(again)))
+	}
+
+	src = g.gen("other.go", "-embed-directive=false", "Mock", "io.Reader")
+	if strings.Contains(src, "go:generate") {
+		t.Errorf("directive with -embed-directive=false:\n%s", src)
+	}
+}
+
+func TestSealed(t *testing.T) {
+	g := newSandbox(t)
+	_, stderr, code := g.run("Mock", "fixture/sealed.Shape", "mock.go")
+	want := "cannot implement sealed interface sealed.Shape (unexported method sealed); generate into package sealed to implement it"
+	if code == 0 || !strings.Contains(stderr, want) {
+		t.Errorf("exit %d, stderr %q, want an error containing %q", code, stderr, want)
+	}
+	if _, stderr, code := g.run("-pkg", "sealed", "Mock", "fixture/sealed.Shape", "../fixture/sealed/mock.go"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	g.write("../fixture/sealed/check.go", "package sealed\n\nvar _ Shape = &Mock{}\n")
+	g.goCmd("vet", "fixture/sealed")
+}
+
+func TestVerbose(t *testing.T) {
+	var log bytes.Buffer
+	l := newLoader()
+	l.logOut = &log
+	if _, _, _, _, err := l.funcs("io.ReadCloser"); err != nil {
+		t.Fatal(err)
+	}
+	contains(t, log.String(), "testgen: resolved io.ReadCloser to io.ReadCloser\n",
+		"testgen: recursing into embedded interface io.Reader of io.ReadCloser\n",
+		"testgen: recursing into embedded interface io.Closer of io.ReadCloser\n")
+
+	// The log goes to stderr, and leaves the output alone.
+	g := newSandbox(t)
+	stdout, stderr, code := g.run("-v", "Mock", "io.ReadCloser")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, stderr, "recursing into embedded interface io.Reader of io.ReadCloser")
+	if quiet, _, _ := g.run("Mock", "io.ReadCloser"); stdout != quiet {
+		t.Errorf("-v changed the output:\n%s", unifiedDiff("quiet", "verbose", []byte(quiet), []byte(stdout)))
+	}
+}
+
+func TestVendored(t *testing.T) {
+	g := newSandbox(t)
+	if _, stderr, code := g.run("-dir", "../app", "Mock", "github.com/dep/pkg.Handler", "../app/mocks/mock.go"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("../app/mocks/mock.go"), `"github.com/dep/pkg"`, "func (t *Mock) Handle(e *pkg.Event) error {")
+	g.write("../app/mocks/mock_test.go", `package mocks
+
+import (
+	"testing"
+
+	"app"
+	"github.com/dep/pkg"
+)
+
+func TestMock(t *testing.T) {
+	var got string
+	m := &Mock{HandleFunc: func(e *pkg.Event) error { got = e.Name; return nil }}
+	if err := app.Dispatch(m, "x"); err != nil || got != "x" {
+		t.Errorf("Dispatch() = %v, handled %q", err, got)
+	}
+}
+`)
+	g.goCmd("test", "app/mocks")
+
+	// go generate runs in app/mocks, so -dir is relative to it.
+	src := g.read("../app/mocks/mock.go")
+	contains(t, src, "//go:generate testgen -dir=.. -recv Mock -iface github.com/dep/pkg.Handler -o mock.go\n")
+	g.goCmd("generate", "app/mocks")
+	if regen := g.read("../app/mocks/mock.go"); regen != src {
+		t.Errorf("go generate changed mock.go:\n%s", unifiedDiff("mock.go", "regenerated", []byte(src), []byte(regen)))
+	}
+
+	// The vendored package isn't found from elsewhere.
+	if _, _, code := g.run("Mock", "github.com/dep/pkg.Handler"); code == 0 {
+		t.Error("found a vendored package outside of its vendor tree")
+	}
+}
+
+func TestEmbedIface(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "embed-iface", g.gen("mock.go", "-embed-iface", "-embed-directive=false", "Mock", "io.ReadWriter"))
+	g.write("mock_test.go", `package out
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Mock{ReadWriter: &buf, ReadFunc: func(p []byte) (int, error) { return 0, nil }}
+	if _, err := m.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.Read(make([]byte, 3)); n != 0 {
+		t.Errorf("Read() = %d, want the ReadFunc result", n)
+	}
+	if buf.String() != "abc" {
+		t.Errorf("Write() wrote %q to the embedded interface", buf.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic with a nil embedded interface")
+		}
+	}()
+	(&Mock{}).Write(nil)
+}
+`)
+	g.goCmd("test", ".")
+	if _, stderr, code := g.run("-embed-iface", "-style", "testify", "Mock", "io.Reader"); code == 0 || !strings.Contains(stderr, "-embed-iface requires -style mock") {
+		t.Errorf("exit %d, stderr %q, want an error about -style", code, stderr)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	g := newSandbox(t)
+	g.write("defaults.go", `package out
+
+import (
+	"context"
+	"time"
+)
+
+var _ context.Context = context.Background()
+var _ time.Duration = 5 * time.Second
+`)
+	contains(t, g.gen("mock.go", "-defaults", "defaults.go", "Mock", "fixture/clock.Clock"),
+		"return context.Background()\n", "return 5 * time.Second\n", "return time.Time{}\n")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	if ctx := m.Context(); ctx == nil || ctx.Err() != nil {
+		t.Errorf("Context() = %v", ctx)
+	}
+	if d := m.Timeout(); d != 5*time.Second {
+		t.Errorf("Timeout() = %v", d)
+	}
+	if now := m.Now(); !now.IsZero() {
+		t.Errorf("Now() = %v", now)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	g.write("defaults.go", "package out\n\nvar x int = 1\n")
+	if _, stderr, code := g.run("-defaults", "defaults.go", "Mock", "fixture/clock.Clock"); code == 0 || !strings.Contains(stderr, "defaults must be declared as var _ T = value") {
+		t.Errorf("exit %d, stderr %q, want an error about the declaration", code, stderr)
+	}
+}
+
+func TestContextResults(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/clock.Scope"), "return context.Background(), func() {}\n")
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	ctx, cancel := (&Mock{}).WithCancel()
+	cancel()
+	if ctx == nil || ctx.Err() != nil {
+		t.Errorf("WithCancel() = %v", ctx)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestOutputDir(t *testing.T) {
+	g := newSandbox(t)
+	g.write("impl/doc.go", "// Package fakes is in the directory impl.\npackage fakes\n")
+	if _, stderr, code := g.run("-o", "impl", "Reader", "io.Reader"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("impl/mock_reader.go"), "package fakes\n", "type Reader struct {")
+	// A file gets the package named after its directory.
+	if _, stderr, code := g.run("-o", "other/reader.go", "Reader", "io.Reader"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("other/reader.go"), "package other\n", "type Reader struct {")
+	g.goCmd("vet", "./impl", "./other")
+}
+
+func TestDirPackage(t *testing.T) {
+	g := newSandbox(t)
+	g.write("utils/util.go", "package util\n")
+	g.write("utils/util_test.go", "package util_test\n")
+	if _, stderr, code := g.run("-o", "utils/mock.go", "Mock", "io.Reader"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("utils/mock.go"), "package util\n")
+	// An empty directory gives a sanitized name.
+	if _, stderr, code := g.run("-o", "my-mocks/mock.go", "Mock", "io.Reader"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("my-mocks/mock.go"), "package mymocks\n")
+	g.goCmd("vet", "./utils", "./my-mocks")
+}
+
+func TestSplit(t *testing.T) {
+	g := newSandbox(t)
+	g.write("rw/doc.go", "package rw\n")
+	if _, stderr, code := g.run("-split", "-o", "rw", "MyMock", "io.Reader,io.Writer,io.ReadWriter"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("rw/mymock.go"), "type MyMock struct {", "ReadFunc ", "WriteFunc ")
+	contains(t, g.read("rw/mymock_reader.go"), "func (t *MyMock) Read(")
+	contains(t, g.read("rw/mymock_writer.go"), "func (t *MyMock) Write(")
+	if _, err := os.Stat(filepath.Join(g.dir, "rw", "mymock_readwriter.go")); !os.IsNotExist(err) {
+		t.Errorf("io.ReadWriter has no methods of its own, but got a file: %v", err)
+	}
+	g.write("rw/mymock_test.go", `package rw
+
+import (
+	"io"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var rw io.ReadWriter = &MyMock{WriteFunc: func(p []byte) (int, error) { return len(p), nil }}
+	if n, err := rw.Write([]byte("ab")); n != 2 || err != nil {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+}
+`)
+	g.goCmd("test", "./rw")
+}
+
+func TestInstantiatedGeneric(t *testing.T) {
+	for _, tc := range []struct{ mode, list string }{
+		{"nil", "nil"},
+		{"alloc", "&coll.List[int]{}"},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			g := newSandbox(t)
+			contains(t, g.gen("mock.go", "-pointer-zero", tc.mode, "Mock", "fixture/coll.Repo"),
+				"func (t *Mock) List() (*coll.List[int], error) {", "return "+tc.list+", nil\n",
+				"return coll.Pair[string, []int]{}\n", "func (t *Mock) Source() coll.Getter[int] {")
+			g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	if l, _ := m.List(); (l == nil) != (`+strconv.Quote(tc.mode)+` == "nil") {
+		t.Errorf("List() = %v", l)
+	}
+	if p := m.Pair(); p.Key != "" || p.Val != nil {
+		t.Errorf("Pair() = %v", p)
+	}
+	if s := m.Source(); s != nil {
+		t.Errorf("Source() = %v", s)
+	}
+}
+`)
+			g.goCmd("test", ".")
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	g := newSandbox(t)
+	stdout, stderr, code := g.run("-list", "Mock", "io.ReadWriteCloser")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	want := "Read(p []byte) (n int, err error)\nWrite(p []byte) (n int, err error)\nClose() error\n"
+	if stdout != want {
+		t.Errorf("got\n%s\nwant\n%s", stdout, want)
+	}
+}
+
+func TestNestedParams(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "nested", g.gen("mock.go", "-embed-directive=false", "Mock", "fixture/nested.Store"))
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/nested"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var got []nested.Option
+	var s nested.Store = &Mock{PutFunc: func(items map[string][]nested.Item, opts ...nested.Option) error {
+		got = opts
+		return nil
+	}}
+	if err := s.Put(map[string][]nested.Item{"a": {1}}, nested.Option{Name: "x"}, nested.Option{Name: "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Name != "y" {
+		t.Errorf("PutFunc got %v", got)
+	}
+	if b := s.Batch(nil, []nested.Item{1}); b[0] != nil || b[1] != nil {
+		t.Errorf("Batch() = %v", b)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestFileLine(t *testing.T) {
+	g := newSandbox(t)
+	stdout, stderr, code := g.run("-pkg", "out", "-file", "../fixture/list/list.go", "-line", "9", "-recv", "Mock")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, stdout, "func (t *Mock) Next() *list.Node {")
+	g.write("mock.go", stdout)
+	g.vet()
+	for _, tc := range []struct {
+		line int
+		want string
+	}{
+		{5, "../fixture/list/list.go:5: Node is not an interface"},
+		{2, "../fixture/list/list.go:2: not inside an interface declaration"},
+	} {
+		_, stderr, code := g.run("-file", "../fixture/list/list.go", "-line", strconv.Itoa(tc.line), "-recv", "Mock")
+		if code == 0 || !strings.Contains(stderr, tc.want) {
+			t.Errorf("-line %d: exit %d, stderr %q, want an error containing %q", tc.line, code, stderr, tc.want)
+		}
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	g := newSandbox(t)
+	for _, args := range [][]string{
+		{"-import", "rand=math/rand", "-import", "mrand=math/rand", "Mock", "fixture/merge.Merger"},
+		{"-style", "testify", "Mock", "fixture/kv.Store"},
+		{"Mock", "net/http.RoundTripper"},
+	} {
+		first, stderr, code := g.run(args...)
+		if code != 0 {
+			t.Fatalf("%v: exit %d\n%s", args, code, stderr)
+		}
+		for i := 0; i < 5; i++ {
+			if again, _, _ := g.run(args...); !bytes.Equal([]byte(again), []byte(first)) {
+				t.Fatalf("%v: run %d differs:\n%s", args, i+2, unifiedDiff("first", "again", []byte(first), []byte(again)))
+			}
+		}
+	}
+}
+
+func TestAny(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/anyx.Bag"),
+		"func (t *Mock) Get(key string) any {", "func (t *Mock) Log(args ...any) {",
+		"func (t *Mock) Closer() interface{ Close() error } {")
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/anyx"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var logged []any
+	var b anyx.Bag = &Mock{LogFunc: func(args ...any) { logged = args }}
+	b.Log(1, "a")
+	if len(logged) != 2 {
+		t.Errorf("LogFunc got %v", logged)
+	}
+	if v := b.Get("k"); v != nil {
+		t.Errorf("Get() = %v", v)
+	}
+	if v := b.Raw(); v != nil {
+		t.Errorf("Raw() = %v", v)
+	}
+	if c := b.Closer(); c != nil {
+		t.Errorf("Closer() = %v", c)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestNamedNumeric(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/sensor.Sensor"), "return *new(temp.Celsius), nil\n")
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	if c, err := (&Mock{}).Read(); c != 0 || err != nil {
+		t.Errorf("Read() = %v, %v", c, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestInterfaceLiteral(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-iface", "interface{ io.Closer; Get(key string) (*bytes.Buffer, error); Len() int }", "-recv", "Mock"),
+		"package out\n", "func (t *Mock) Close() error {", "func (t *Mock) Get(key string) (*bytes.Buffer, error) {")
+	g.write("mock_test.go", `package out
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var m interface {
+		Close() error
+		Get(key string) (*bytes.Buffer, error)
+		Len() int
+	} = &Mock{LenFunc: func() int { return 2 }}
+	if n := m.Len(); n != 2 {
+		t.Errorf("Len() = %d", n)
+	}
+	if b, err := m.Get("k"); b != nil || err != nil {
+		t.Errorf("Get() = %v, %v", b, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	if _, stderr, code := g.run("-iface", "interface{ Get() Value }", "-recv", "Mock"); code == 0 || !strings.Contains(stderr, "type Value in interface literal must be qualified by its package") {
+		t.Errorf("exit %d, stderr %q, want an error about Value", code, stderr)
+	}
+}
+
+func TestAsserts(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "asserts", g.gen("mock.go", "-capture", "-asserts", "-embed-directive=false", "Mock", "fixture/logger.Logger"))
+	g.write("mock_test.go", `package out
+
+import (
+	"fmt"
+	"testing"
+)
+
+// tb records the errors reported to it.
+type tb struct {
+	testing.TB
+	errs []string
+}
+
+func (tb *tb) Helper() {}
+
+func (tb *tb) Errorf(format string, args ...interface{}) {
+	tb.errs = append(tb.errs, fmt.Sprintf(format, args...))
+}
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	var rec tb
+	m.AssertLogfCalledWith(&rec, "x")
+	if len(rec.errs) != 1 || rec.errs[0] != "Mock.Logf was not called" {
+		t.Errorf("before a call: %q", rec.errs)
+	}
+
+	m.Logf("a %d", 1)
+	m.Logf("b %d %s", 2, "c")
+	if len(m.LogfCalls) != 2 || m.LogfCalls[0].Format != "a %d" {
+		t.Errorf("LogfCalls = %+v", m.LogfCalls)
+	}
+	rec.errs = nil
+	m.AssertLogfCalledWith(&rec, "b %d %s", 2, "c")
+	if rec.errs != nil {
+		t.Errorf("matching call: %q", rec.errs)
+	}
+	m.AssertLogfCalledWith(&rec, "b %d %s", 2)
+	if len(rec.errs) != 1 {
+		t.Errorf("call with other variadic args: %q", rec.errs)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	if _, stderr, code := g.run("-asserts", "Mock", "io.Reader"); code == 0 || !strings.Contains(stderr, "-asserts requires -capture") {
+		t.Errorf("exit %d, stderr %q, want an error about -capture", code, stderr)
+	}
+}
+
+func TestPackageOut(t *testing.T) {
+	g := newSandbox(t)
+	if _, stderr, code := g.run("-package-out", "mocks", "Reader", "io.Reader"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("mocks/readermock/doc.go"), "// Package readermock provides Reader, a mock of io.Reader.\npackage readermock\n", "func New() *Reader {")
+	contains(t, g.read("mocks/readermock/mock_reader.go"), "package readermock\n")
+	g.goCmd("build", "./mocks/readermock")
+	// The package is importable.
+	g.write("use_test.go", `package out
+
+import (
+	"io"
+	"testing"
+
+	"out/mocks/readermock"
+)
+
+func TestNew(t *testing.T) {
+	var r io.Reader = readermock.New()
+	if n, err := r.Read(nil); n != 0 || err != nil {
+		t.Errorf("Read() = %d, %v", n, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestSelfReference(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/chain.Chain"), "return t\n", "return t, nil\n")
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/chain"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	var c chain.Chain = m
+	if c.Next() != m || c.Wrap() != m {
+		t.Error("want the mock itself")
+	}
+	if o, err := c.Or(nil); o != m || err != nil {
+		t.Errorf("Or() = %v, %v", o, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	if _, stderr, code := g.run("Mock", "fixture/loop.A"); code == 0 || !strings.Contains(stderr, "interface fixture/loop.A embeds itself") {
+		t.Errorf("exit %d, stderr %q, want an error about embedding", code, stderr)
+	}
+}
+
+func TestComment(t *testing.T) {
+	g := newSandbox(t)
+	src := g.gen("mock.go", "-comment", "{{.Name}} implements {{.Iface}}.{{.Name}}.", "-struct-comment", "{{.Recv}} is a fake {{.Iface}}.", "Mock", "fixture/doc.Store")
+	contains(t, src, "// Mock is a fake doc.Store.\ntype Mock struct {", "// Len implements doc.Store.Len.\nfunc (t *Mock) Len() int {",
+		"// Get returns the value of key.\n//\n// It returns false if key is not set.\nfunc (t *Mock) Get(")
+	g.vet()
+	if _, stderr, code := g.run("-comment", "{{.Bogus}}", "Mock", "fixture/doc.Store"); code == 0 || !strings.Contains(stderr, "invalid comment") {
+		t.Errorf("exit %d, stderr %q, want an invalid comment error", code, stderr)
+	}
+}
+
+func TestMarker(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/marker.Sentinel"), "type Mock struct {\n}\n", "var _ marker.Sentinel = (*Mock)(nil)\n")
+	g.vet()
+}
+
+func TestDelegate(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "delegate", g.gen("mock.go", "-delegate", "-embed-directive=false", "Mock", "fixture/kv.Store"))
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/kv"
+	"testing"
+)
+
+// store overrides Get of a kv.Store.
+type store struct {
+	kv.Store
+	v string
+}
+
+func (s store) Get(string) (string, error) { return s.v, nil }
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	if v, err := m.Get("k"); v != "" || err != nil {
+		t.Errorf("nil Impl: Get() = %q, %v", v, err)
+	}
+	m.Reset()
+
+	m.Impl = store{v: "a"}
+	if v, _ := m.Get("k"); v != "a" {
+		t.Errorf("Get() = %q, want a", v)
+	}
+	m.Impl = store{v: "b"}
+	if v, _ := m.Get("k"); v != "b" {
+		t.Errorf("after swapping: Get() = %q, want b", v)
+	}
+	m.Impl = nil
+	if err := m.Put("k", nil); err != nil {
+		t.Errorf("Put() = %v", err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	if _, stderr, code := g.run("-delegate", "-capture", "Mock", "io.Reader"); code == 0 || !strings.Contains(stderr, "-delegate requires -style mock") {
+		t.Errorf("exit %d, stderr %q, want a -delegate error", code, stderr)
+	}
+}
+
+func TestReturns(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/results.Results"),
+		"func (t *Mock) None() {\n\tif t.NoneFunc != nil {\n\t\tt.NoneFunc()\n\t\treturn\n\t}\n}\n",
+		"\treturn nil\n}\n\n// NamedErr ",
+		"\treturn 0, nil\n}\n",
+		"\treturn \"\", nil, nil\n}\n",
+		"\treturn false, false, false\n}\n")
+	g.write("mock_test.go", `package out
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	m.None()
+	if err := m.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+	if err := m.NamedErr(); err != nil {
+		t.Errorf("NamedErr() = %v", err)
+	}
+	if n, err := m.Two(); n != 0 || err != nil {
+		t.Errorf("Two() = %d, %v", n, err)
+	}
+	if s, p, err := m.Three(); s != "" || len(p) != 0 || err != nil {
+		t.Errorf("Three() = %q, %q, %v", s, p, err)
+	}
+	if a, b, c := m.Bools(); a || b || c {
+		t.Errorf("Bools() = %t, %t, %t", a, b, c)
+	}
+
+	want := errors.New("x")
+	m.ThreeFunc = func() (string, []byte, error) { return "s", []byte("p"), want }
+	if s, p, err := m.Three(); s != "s" || string(p) != "p" || err != want {
+		t.Errorf("Three() = %q, %q, %v", s, p, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestOutputPath(t *testing.T) {
+	g := newSandbox(t)
+	g.gen("mock.go", "Mock", "io.Reader")
+	g.write("nested/doc.go", "package nested\n")
+	contains(t, g.gen("nested/mock.go", "Mock", "io.Reader"), "package nested\n")
+	if _, stderr, code := g.run("-pkg", "abs", "Mock", "io.Reader", filepath.Join(g.dir, "abs", "mock.go")); code != 0 {
+		t.Fatalf("absolute out: exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("abs/mock.go"), "package abs\n")
+	if _, stderr, code := g.run("-gopath", "Mock", "io.Reader", "out/gopath/mock.go"); code != 0 {
+		t.Fatalf("-gopath: exit %d\n%s", code, stderr)
+	}
+	g.read("gopath/mock.go")
+	g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"testing"
+
+	"out/abs"
+	"out/nested"
+)
+
+func TestMock(t *testing.T) {
+	for _, r := range []io.Reader{&Mock{}, &nested.Mock{}, &abs.Mock{}} {
+		if n, err := r.Read(nil); n != 0 || err != nil {
+			t.Errorf("Read() = %d, %v", n, err)
+		}
+	}
+}
+`)
+	g.goCmd("test", "./...")
+}
+
+func TestExitCodes(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		code int
+		msg  string
+	}{
+		{[]string{"-split", "-o", "missing", "Mock", "io.Reader"}, 2, "-split requires -o to be a directory"},
+		{[]string{"Mock", "io.Reader,io.Writer"}, 2, "implementing several interfaces requires -split"},
+		{[]string{"Mock", "fixture/kv.Missing"}, 3, "interface fixture/kv.Missing not found"},
+		{[]string{"Mock", "bytes.Buffer"}, 4, "not an interface: bytes.Buffer"},
+		{[]string{"Mock", "io.Reader io.Writer"}, 5, "couldn't parse interface: io.Reader io.Writer"},
+		{[]string{"Mock", "fixture/loop.A"}, 5, "interface fixture/loop.A embeds itself"},
+		{[]string{"-o", "mock.go", "Mock", "fixture/multi.Sealed"}, 5, "cannot implement sealed interface multi.Sealed (unexported method seal)"},
+		{[]string{"-o", "mock.go", "Mock", "fixture/multi.Entries"}, 5, "method Next of multi.Entries uses unexported type multi.entry"},
+	} {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			g := newSandbox(t)
+			if _, stderr, code := g.run(tc.args...); code != tc.code || !strings.Contains(stderr, tc.msg) {
+				t.Errorf("exit %d, stderr %q, want exit %d and %q", code, stderr, tc.code, tc.msg)
+			}
+		})
+	}
+	t.Run("-diff", func(t *testing.T) {
+		g := newSandbox(t)
+		g.gen("mock.go", "Mock", "io.Reader")
+		if _, _, code := g.run("-diff", "Mock", "io.Reader", "mock.go"); code != 0 {
+			t.Errorf("exit %d without differences, want 0", code)
+		}
+		if _, _, code := g.run("-diff", "Mock", "io.ReadCloser", "mock.go"); code != 1 {
+			t.Errorf("exit %d with differences, want 1", code)
+		}
+	})
+}
+
+func TestRunFlags(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		code int
+		msg  string
+	}{
+		{nil, 2, "testgen [flags] <recv type> <iface> [out]"},
+		{[]string{"-help"}, 0, "testgen [flags] <recv type> <iface> [out]"},
+		{[]string{"-bogus", "Mock", "io.Reader"}, 2, "flag provided but not defined: -bogus"},
+		{[]string{"-file", "x.go", "-iface", "io.Reader", "Mock"}, 2, "-file cannot be used with -iface"},
+		{[]string{"-match", "(", "Mock", "io.Reader"}, 2, "invalid -match: error parsing regexp"},
+		{[]string{"-sync", "always", "-capture", "Mock", "io.Reader"}, 2, "invalid -sync: always"},
+		{[]string{"-pointer-zero", "new", "Mock", "io.Reader"}, 2, "invalid -pointer-zero: new"},
+		{[]string{"-rname", "1t", "Mock", "io.Reader"}, 2, "invalid receiver name: 1t"},
+		{[]string{"-style", "fake", "Mock", "io.Reader"}, 2, "invalid -style: fake"},
+		{[]string{"-asserts", "Mock", "io.Reader"}, 2, "-asserts requires -capture or -spy"},
+		{[]string{"-only", "Raed", "Mock", "io.Reader"}, 2, "io.Reader has no method Raed"},
+		{[]string{"-import", "rand=nope/rand", "Mock", "rand.Source"}, 2, `invalid value "rand=nope/rand" for flag -import: couldn't find package nope/rand`},
+		{[]string{"-spec", "Reader=io.Reader", "-iface", "io.Reader"}, 2, "-spec and -pkg-all cannot be used with -recv, -iface"},
+		{[]string{"-diff", "Mock", "io.Reader"}, 2, "-diff requires an output file"},
+	} {
+		t.Run(strings.Join(tc.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tc.args, &stdout, &stderr); code != tc.code || !strings.Contains(stderr.String(), tc.msg) {
+				t.Errorf("exit %d, stderr %q, want exit %d and %q", code, stderr.String(), tc.code, tc.msg)
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("stdout %q, want nothing", stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunState(t *testing.T) {
+	// The flags of one run don't carry over to the next.
+	for _, tc := range []struct {
+		args      []string
+		code      int
+		want, not string
+	}{
+		{[]string{"-only", "Read", "Mock", "io.ReadCloser"}, 0, "func (t *Mock) Read(", "func (t *Mock) Close("},
+		{[]string{"Mock", "io.ReadCloser"}, 0, "func (t *Mock) Close(", "Read is not implemented"},
+		{[]string{"-import", "rand=crypto/rand", "Mock", "rand.Source"}, 3, "", "func"},
+		{[]string{"Mock", "rand.Source"}, 0, "Int63Func func() int64", "crypto/rand"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(tc.args, &stdout, &stderr); code != tc.code {
+			t.Fatalf("%s: exit %d, want %d\n%s", tc.args, code, tc.code, stderr.String())
+		}
+		if src := stdout.String(); !strings.Contains(src, tc.want) || strings.Contains(src, tc.not) {
+			t.Errorf("%s: want %q and not %q in\n%s", tc.args, tc.want, tc.not, src)
+		}
+	}
+}
+
+func TestConcrete(t *testing.T) {
+	g := newSandbox(t)
+	src := g.gen("mock.go", "-concrete", "Mock", "fixture/conc.Client")
+	contains(t, src, "// Addr returns the address of the client.\nfunc (t *Mock) Addr() string {", "func (t *Mock) Send(w io.Writer, msg []byte) (int, error) {")
+	if strings.Contains(src, "reset") || strings.Contains(src, "var _") {
+		t.Errorf("unexported method or assertion in\n%s", src)
+	}
+	g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"testing"
+)
+
+// sender is satisfied by *conc.Client.
+type sender interface {
+	Addr() string
+	Send(w io.Writer, msg []byte) (int, error)
+}
+
+func TestMock(t *testing.T) {
+	var s sender = &Mock{AddrFunc: func() string { return "a" }}
+	if s.Addr() != "a" {
+		t.Errorf("Addr() = %q", s.Addr())
+	}
+	if n, err := s.Send(nil, nil); n != 0 || err != nil {
+		t.Errorf("Send() = %d, %v", n, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	if _, stderr, code := g.run("Mock", "fixture/conc.Client"); code != 4 || !strings.Contains(stderr, "use -concrete") {
+		t.Errorf("exit %d, stderr %q, want exit 4 suggesting -concrete", code, stderr)
+	}
+}
+
+// BenchmarkLoadPkg loads net/http, one of the largest packages of the
+// standard library.
+func BenchmarkLoadPkg(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := newLoader().loadPkg("net/http", ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParse compares parsing the files of net/http with and without
+// resolving identifiers to objects, which loadPkg skips.
+func BenchmarkParse(b *testing.B) {
+	pkg, err := build.Import("net/http", "", 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	var srcs [][]byte
+	for _, file := range pkg.GoFiles {
+		src, err := ioutil.ReadFile(filepath.Join(pkg.Dir, file))
+		if err != nil {
+			b.Fatal(err)
+		}
+		srcs = append(srcs, src)
+	}
+	for _, bc := range []struct {
+		name string
+		mode parser.Mode
+	}{
+		{"resolve", parser.ParseComments},
+		{"skip", parser.ParseComments | parser.SkipObjectResolution},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				fset := token.NewFileSet()
+				for _, src := range srcs {
+					if _, err := parser.ParseFile(fset, "", src, bc.mode); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAppend(t *testing.T) {
+	g := newSandbox(t)
+	g.gen("mock.go", "Mock", "io.Reader")
+	src := g.gen("mock.go", "-append", "Mock", "io.Closer")
+	contains(t, src, "\tReadFunc  func(p []byte) (n int, err error)\n\tCloseFunc func() error\n}\n", "func (t *Mock) Read(p []byte) (n int, err error) {", "func (t *Mock) Close() error {")
+	if again := g.gen("mock.go", "-append", "Mock", "io.ReadCloser"); again != src {
+		t.Errorf("appending implemented methods changed the file:\n%s", unifiedDiff("before", "after", []byte(src), []byte(again)))
+	}
+	g.write("mock_test.go", `package out
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	want := errors.New("closed")
+	var rc io.ReadCloser = &Mock{CloseFunc: func() error { return want }}
+	if n, err := rc.Read(nil); n != 0 || err != nil {
+		t.Errorf("Read() = %d, %v", n, err)
+	}
+	if err := rc.Close(); err != want {
+		t.Errorf("Close() = %v, want %v", err, want)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	// go generate regenerates the mock of io.Reader, then appends io.Closer.
+	contains(t, src, "//go:generate testgen -recv Mock -iface io.Reader -o mock.go\n//go:generate testgen -append=true -recv Mock -iface io.Closer -o mock.go\n")
+	g.goCmd("generate")
+	if regen := g.read("mock.go"); regen != src {
+		t.Errorf("go generate changed mock.go:\n%s", unifiedDiff("mock.go", "regenerated", []byte(src), []byte(regen)))
+	}
+
+	// Appended imports are grouped by -local, and only sorted with -noformat.
+	g.gen("local.go", "-local", "fixture", "Local", "io.Reader")
+	contains(t, g.gen("local.go", "-append", "-local", "fixture", "Local", "fixture/kv.Store"), "import (\n\t\"io\"\n\n\t\"fixture/kv\"\n)\n")
+	g.gen("noformat.go", "-noformat", "-local", "fixture", "NoFormat", "io.Reader")
+	contains(t, g.gen("noformat.go", "-append", "-noformat", "-local", "fixture", "NoFormat", "fixture/kv.Store"), "import (\n\t\"fixture/kv\"\n\t\"io\"\n)\n")
+	g.vet()
+}
+
+func TestConfig(t *testing.T) {
+	g := newSandbox(t)
+	g.write("defaults.go", "package out\n\nimport \"time\"\n\nvar _ time.Time = time.Unix(0, 0)\n")
+	g.write("../.testgen.yaml", `# shared by the packages of src
+rname: m
+header: "// Code generated by testgen from {{.Iface}}; DO NOT EDIT."
+strict: true
+defaults: 'out/defaults.go'
+`)
+	src := g.gen("mock.go", "-embed-directive=false", "Mock", "fixture/clock.Clock")
+	contains(t, src, "// Code generated by testgen from clock.Clock; DO NOT EDIT.\npackage out\n", "func (m *Mock) Now() time.Time {", `panic("Mock.Now: not implemented")`)
+	contains(t, g.gen("mock.go", "-rname", "x", "-strict=false", "Mock", "fixture/clock.Clock"), "func (x *Mock) Now() time.Time {", "\treturn time.Unix(0, 0)\n")
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	if now := (&Mock{}).Now(); now.Unix() != 0 {
+		t.Errorf("Now() = %v, want the default of defaults.go", now)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	for _, tc := range []struct{ yaml, want string }{
+		{"suffix: Fn\n", "line 1: field suffix not found"},
+		{"rname: m\nstrict: maybe\n", "line 2: cannot unmarshal !!str `maybe` into bool"},
+		{"pointer-zero: [nil]\n", "line 1: cannot unmarshal !!seq into string"},
+	} {
+		g.write("../.testgen.yaml", tc.yaml)
+		if _, stderr, code := g.run("Mock", "io.Reader"); code == 0 || !strings.Contains(stderr, ".testgen.yaml: ") || !strings.Contains(stderr, tc.want) {
+			t.Errorf("%q: exit %d, stderr %q, want an error containing %q", tc.yaml, code, stderr, tc.want)
+		}
+	}
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	str := func(s string) *string { return &s }
+	yes, no := true, false
+	cfg := Config{
+		Style: str("testify"), Header: str("// Code generated by testgen; DO NOT EDIT.\n\n// +build !prod"), RecvName: str("m"),
+		PointerZero: str("alloc"), Defaults: str("testdata/defaults.go"), Strict: &yes, Comment: str("{{.Name}} implements {{.Iface}}."),
+		StructComment: str("# {{.Recv}}: a mock"), Capture: &yes, Asserts: &no, Tags: str("integration,linux"), Local: str("example.com/proj"),
+		NoFormat: &yes, SmartDefaults: &no, LintSuppress: &yes, Sync: str("fine"), NoGenHeader: &no,
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "testgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, ".testgen.yaml"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readConfig(filepath.Join(dir, ".testgen.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("read back\n%+v\nwant\n%+v\nfrom\n%s", got, cfg, data)
+	}
+
+	// Each setting sets its flag, and the others keep their defaults.
+	c := newCommand(ioutil.Discard, ioutil.Discard)
+	if err := loadConfig(c.flags, filepath.Join(dir, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	if c.style != "testify" || c.header != *cfg.Header || c.recvName != "m" || c.pointerZero != "alloc" || c.defaults != filepath.Join(dir, "testdata/defaults.go") ||
+		!c.strict || c.comment != *cfg.Comment || c.structComment != *cfg.StructComment || !c.capture || c.asserts || c.tags != "integration,linux" ||
+		c.local != "example.com/proj" || !c.noFormat || c.smartDefaults || !c.lintSuppress || c.syncMode != "fine" || c.noGenHeader {
+		t.Errorf("flags not set from\n%s", data)
+	}
+	if c.recvFlag != "" || !c.embedDirective {
+		t.Errorf("flags the config doesn't set changed")
+	}
+}
+
+func TestCallbackParams(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/walk.Walker"),
+		"\tWalkFunc   func(root string, fn func(path string, info walk.Info) error) error\n",
+		"\tFilterFunc func() func(info *walk.Info) (skip bool, err error)\n",
+		"func (t *Mock) Walk(root string, fn func(path string, info walk.Info) error) error {",
+		"\treturn nil\n}\n\n// Events ")
+	g.write("mock_test.go", `package out
+
+import (
+	"errors"
+	"fixture/walk"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	want := errors.New("stop")
+	m := &Mock{WalkFunc: func(root string, fn func(string, walk.Info) error) error {
+		return fn(root+"/a", walk.Info{Name: "a"})
+	}}
+	var w walk.Walker = m
+	var got string
+	err := w.Walk("r", func(path string, info walk.Info) error {
+		got = path + ":" + info.Name
+		return want
+	})
+	if err != want || got != "r/a:a" {
+		t.Errorf("Walk() = %v, callback got %q", err, got)
+	}
+	if w.Filter() != nil || w.Events() != nil {
+		t.Error("want nil func and chan results")
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestRaw(t *testing.T) {
+	g := newSandbox(t)
+	raw, stderr, code := g.run("-raw", "-pkg", "out", "Mock", "io.ReadCloser")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	formatted, _, _ := g.run("-pkg", "out", "Mock", "io.ReadCloser")
+	if raw == formatted {
+		t.Fatalf("-raw output is formatted:\n%s", raw)
+	}
+	contains(t, raw, "import (\n\t \"io\"\n)\n", "func (t *Mock)Read(p []byte, ) (n int, err error, ) {")
+	contains(t, formatted, "import (\n\t\"io\"\n)\n", "func (t *Mock) Read(p []byte) (n int, err error) {")
+	// goimports only formats the raw output, which declares its imports.
+	g.write("mock.go", raw)
+	g.goCmd("fmt", ".")
+	if got := g.read("mock.go"); got != formatted {
+		t.Errorf("formatted -raw output differs:\n%s", unifiedDiff("raw", "formatted", []byte(got), []byte(formatted)))
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "builder", g.gen("mock.go", "-builder", "-embed-directive=false", "Mock", "fixture/kv.Store"))
+	g.write("mock_test.go", `package out
+
+import (
+	"errors"
+	"fixture/kv"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	want := errors.New("read-only")
+	m := new(Mock).
+		WithGet(func(key string) (string, error) { return "v:" + key, nil }).
+		WithPut(func(string, []byte) error { return want })
+	var s kv.Store = m
+	if v, err := s.Get("k"); v != "v:k" || err != nil {
+		t.Errorf("Get() = %q, %v", v, err)
+	}
+	if err := s.Put("k", nil); err != want {
+		t.Errorf("Put() = %v, want %v", err, want)
+	}
+	if m.WithReset(func() {}) != m {
+		t.Error("WithReset() doesn't return the mock")
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	if _, stderr, code := g.run("-builder", "Mock", "fixture/with.Options"); code == 0 || !strings.Contains(stderr, "builder method WithTimeout collides with the method of the same name") {
+		t.Errorf("exit %d, stderr %q, want a collision error", code, stderr)
+	}
+}
+
+func TestOwnPackage(t *testing.T) {
+	g := newSandbox(t)
+	if _, stderr, code := g.run("-embed-directive=false", "-pkg", "own", "Mock", "fixture/own.Handler", "../fixture/own/mock.go"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	src := g.read("../fixture/own/mock.go")
+	contains(t, src, "\tServeFunc  func(r *Request) (*Response, error)\n", "\tRoutesFunc func() map[string]Handler\n", "var _ Handler = (*Mock)(nil)\n")
+	if strings.Contains(src, "own.") {
+		t.Errorf("qualified types of the own package in\n%s", src)
+	}
+	g.write("../fixture/own/mock_test.go", `package own
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	var h Handler = &Mock{ServeFunc: func(r *Request) (*Response, error) { return &Response{Status: 200}, nil }}
+	if resp, err := h.Serve(&Request{}); resp.Status != 200 || err != nil {
+		t.Errorf("Serve() = %+v, %v", resp, err)
+	}
+	if len(h.Routes()) != 0 {
+		t.Error("want no routes")
+	}
+}
+`)
+	g.goCmd("test", "fixture/own")
+}
+
+func TestConstraint(t *testing.T) {
+	for _, iface := range []string{"fixture/constraint.Key", "fixture/constraint.Ordered", "fixture/constraint.Clock", "interface{ ~int; String() string }"} {
+		g := newSandbox(t)
+		_, stderr, code := g.run("Mock", iface)
+		if want := iface + " is a type constraint, not an implementable interface"; code != 4 || !strings.Contains(stderr, want) {
+			t.Errorf("%s: exit %d, stderr %q, want exit 4 and %q", iface, code, stderr, want)
+		}
+	}
+}
+
+func TestOddShapes(t *testing.T) {
+	for _, iface := range []string{
+		"*io.Reader",
+		"[]io.Reader",
+		"io.Reader)",
+		"interface{ Read",
+		"interface{ io.Reader }; var x int",
+		"interface{ Read(p []byte) (int, error) }{}",
+		"func()",
+		"map[string]io.Reader",
+	} {
+		if _, _, _, _, err := newLoader().funcs(iface); err == nil || exitCode(err) == exitFailure {
+			t.Errorf("funcs(%q): got %v, want a classified error", iface, err)
+		}
+	}
+}
+
+// FuzzFuncs checks that funcs returns an error rather than panicking on
+// malformed interfaces.
+func FuzzFuncs(f *testing.F) {
+	for _, seed := range []string{"io.Reader", "*io.Reader", "interface{ Close() error }", "interface{ ~int }", "net/http.", "a/b.c.d", "interface{ x int }"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, iface string) {
+		newLoader().funcs(iface)
+	})
+}
+
+func TestQueue(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-queue", "MockReader", "io.Reader"), "\tReadReturns []MockReaderReadReturn\n", "type MockReaderReadReturn struct {\n\tR0 int\n\tR1 error\n}\n")
+	g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var r io.Reader = &MockReader{ReadReturns: []MockReaderReadReturn{{3, nil}, {0, io.EOF}}}
+	for i, want := range []MockReaderReadReturn{{3, nil}, {0, io.EOF}, {0, nil}} {
+		if n, err := r.Read(nil); n != want.R0 || err != want.R1 {
+			t.Errorf("call %d: Read() = %d, %v, want %d, %v", i+1, n, err, want.R0, want.R1)
+		}
+	}
+	if n := len(r.(*MockReader).ReadReturns); n != 0 {
+		t.Errorf("%d results left", n)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestQualifiedRecv(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "out.Mock", "io.Reader"), "package out\n", "type Mock struct {", "func (t *Mock) Read(")
+	stdout, stderr, code := g.run("mocks.Reader", "io.Reader")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, stdout, "package mocks\n", "type Reader struct {")
+	g.write("mocks/mock.go", stdout)
+	g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"testing"
+
+	"out/mocks"
+)
+
+func TestMock(t *testing.T) {
+	for _, r := range []io.Reader{&Mock{}, &mocks.Reader{}} {
+		if n, err := r.Read(nil); n != 0 || err != nil {
+			t.Errorf("Read() = %d, %v", n, err)
+		}
+	}
+}
+`)
+	g.goCmd("test", "./...")
+
+	for _, tc := range []struct {
+		recv, msg string
+	}{
+		{"other.Mock", "receiver other.Mock cannot be declared in package out"},
+		{"a.b.Mock", "invalid receiver type: a.b.Mock"},
+		{"*Mock", "invalid receiver type: *Mock"},
+	} {
+		if _, stderr, code := g.run(tc.recv, "io.Reader", "mock.go"); code != 2 || !strings.Contains(stderr, tc.msg) {
+			t.Errorf("%s: exit %d, stderr %q, want exit 2 and %q", tc.recv, code, stderr, tc.msg)
+		}
+	}
+}
+
+func TestGenericMock(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-capture", "Mock", "fixture/cache.Cache"),
+		"type Mock[K comparable, V any] struct {", "func (t *Mock[K, V]) Get(key K) (V, bool) {", "\treturn *new(V), false\n", "\tPutCalls  []MockPutCall[K, V]\n")
+	contains(t, g.gen("counter.go", "Counter", "fixture/cache.Counter"),
+		"type Counter[K comparable, N cache.Number] struct {", "\treturn *new(N)\n")
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/cache"
+	"testing"
+	"time"
+)
+
+func TestMock(t *testing.T) {
+	var c cache.Cache[string, int] = &Mock[string, int]{GetFunc: func(key string) (int, bool) { return len(key), true }}
+	if v, ok := c.Get("abc"); v != 3 || !ok {
+		t.Errorf("Get() = %d, %t", v, ok)
+	}
+	c.Put("k", 1, time.Second)
+	if calls := c.(*Mock[string, int]).PutCalls; len(calls) != 1 || calls[0].Key != "k" || calls[0].V != 1 {
+		t.Errorf("PutCalls = %+v", calls)
+	}
+
+	var n cache.Counter[string, float64] = &Counter[string, float64]{}
+	if got := n.Add("k", 1.5); got != 0 {
+		t.Errorf("Add() = %v, want 0", got)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestOnlySkip(t *testing.T) {
+	g := newSandbox(t)
+	// has fails unless src declares exactly methods of io.ReadWriteCloser.
+	has := func(src string, methods ...string) {
+		t.Helper()
+		for _, m := range []string{"Read", "Write", "Close"} {
+			want := strings.Contains(strings.Join(methods, ","), m)
+			if got := strings.Contains(src, ") "+m+"("); got != want {
+				t.Errorf("method %s declared: %t, want %t in\n%s", m, got, want, src)
+			}
+		}
+	}
+	src := g.gen("only.go", "-only", "Read,Close", "Only", "io.ReadWriteCloser")
+	has(src, "Read", "Close")
+	if strings.Contains(src, "var _") {
+		t.Errorf("assertion of a partial mock in\n%s", src)
+	}
+	has(g.gen("skip.go", "-skip", "Read", "-skip", "Close", "Skip", "io.ReadWriteCloser"), "Write")
+	has(g.gen("both.go", "-only", "Read,Write", "-skip", "Write", "Both", "io.ReadWriteCloser"), "Read")
+	g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var _ io.ReadCloser = &Only{}
+	var _ io.Writer = &Skip{}
+	var _ io.Reader = &Both{}
+	if err := (&Only{}).Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	for _, tc := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"-only", "Raed"}, "io.ReadWriteCloser has no method Raed"},
+		{[]string{"-skip", "Close,Flush"}, "io.ReadWriteCloser has no method Flush"},
+		{[]string{"-only", "Read,"}, "invalid method name: "},
+	} {
+		if _, stderr, code := g.run(append(tc.args, "Mock", "io.ReadWriteCloser")...); code != 2 || !strings.Contains(stderr, tc.msg) {
+			t.Errorf("%v: exit %d, stderr %q, want exit 2 and %q", tc.args, code, stderr, tc.msg)
+		}
+	}
+}
+
+func TestSpy(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-spy", "-asserts", "Spy", "io.ReadWriter"), "\tReal io.ReadWriter\n", "\tWriteCalls []SpyWriteCall\n")
+	g.write("mock_test.go", `package out
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSpy(t *testing.T) {
+	var buf bytes.Buffer
+	s := &Spy{Real: &buf}
+	var rw io.ReadWriter = s
+
+	// Delegation.
+	if n, err := rw.Write([]byte("hello")); n != 5 || err != nil {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+	if buf.String() != "hello" {
+		t.Errorf("Real got %q, want hello", buf.String())
+	}
+	s.AssertWriteCalledWith(t, []byte("hello"))
+
+	// Override.
+	want := errors.New("full")
+	s.WriteFunc = func([]byte) (int, error) { return 0, want }
+	if _, err := rw.Write([]byte("world")); err != want {
+		t.Errorf("overridden Write() = %v, want %v", err, want)
+	}
+	if buf.String() != "hello" {
+		t.Errorf("Real called despite the override: %q", buf.String())
+	}
+	if len(s.WriteCalls) != 2 || string(s.WriteCalls[1].P) != "world" {
+		t.Errorf("WriteCalls = %q", s.WriteCalls)
+	}
+
+	p := make([]byte, 5)
+	if n, _ := rw.Read(p); string(p[:n]) != "hello" || len(s.ReadCalls) != 1 {
+		t.Errorf("Read() = %q, ReadCalls = %d", p[:n], len(s.ReadCalls))
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestTags(t *testing.T) {
+	g := newSandbox(t)
+	if _, stderr, code := g.run("Mock", "fixture/tagged.Store"); code != 3 || !strings.Contains(stderr, "interface fixture/tagged.Store not found") {
+		t.Errorf("without tags: exit %d, stderr %q, want exit 3", code, stderr)
+	}
+	contains(t, g.gen("mock.go", "-tags", "integration", "Mock", "fixture/tagged.Store"), "func (t *Mock) Load(key string) ([]byte, error) {")
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/tagged"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var s tagged.Store = &Mock{LoadFunc: func(key string) ([]byte, error) { return []byte(key), nil }}
+	if b, err := s.Load("k"); string(b) != "k" || err != nil {
+		t.Errorf("Load() = %q, %v", b, err)
+	}
+}
+`)
+	g.goCmd("test", "-tags", "integration", ".")
+
+	// The tags may come from .testgen.yaml too.
+	g.write("../.testgen.yaml", "tags: integration\n")
+	g.gen("mock.go", "Mock", "fixture/tagged.Store")
+}
+
+func TestBuildTags(t *testing.T) {
+	for _, tc := range []struct {
+		tags, goflags string
+		want          []string
+	}{
+		{"", "", nil},
+		{"a,b", "-tags=c", []string{"a", "b"}},
+		{"", "-mod=mod -tags=c,d", []string{"c", "d"}},
+		{"", "--tags=e", []string{"e"}},
+		{" a , ,b", "", []string{"a", "b"}},
+	} {
+		if got := buildTags(tc.tags, tc.goflags); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("buildTags(%q, %q) = %q, want %q", tc.tags, tc.goflags, got, tc.want)
+		}
+	}
+}
+
+func TestExpectClose(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-capture", "-expect-close", "Mock", "io.Closer"), "func (t *Mock) ExpectClosed(_tb testing.TB) {")
+	g.write("mock_test.go", `package out
+
+import (
+	"fmt"
+	"testing"
+)
+
+// tb records the errors reported to it.
+type tb struct {
+	testing.TB
+	errs []string
+}
+
+func (tb *tb) Helper() {}
+
+func (tb *tb) Errorf(format string, args ...interface{}) {
+	tb.errs = append(tb.errs, fmt.Sprintf(format, args...))
+}
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	var rec tb
+	m.ExpectClosed(&rec)
+	if len(rec.errs) != 1 || rec.errs[0] != "Mock.Close was not called" {
+		t.Errorf("un-closed mock: %q", rec.errs)
+	}
+
+	rec.errs = nil
+	m.Close()
+	m.ExpectClosed(&rec)
+	if rec.errs != nil {
+		t.Errorf("closed mock: %q", rec.errs)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	for _, tc := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"-expect-close", "Mock", "io.Closer"}, "-expect-close requires -capture or -spy"},
+		{[]string{"-capture", "-expect-close", "Mock", "io.Reader"}, "-expect-close requires io.Reader to have a Close method without params"},
+	} {
+		if _, stderr, code := g.run(tc.args...); code != 2 || !strings.Contains(stderr, tc.msg) {
+			t.Errorf("%v: exit %d, stderr %q, want exit 2 and %q", tc.args, code, stderr, tc.msg)
+		}
+	}
+}
+
+func TestChans(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "chans", g.gen("mock.go", "-embed-directive=false", "Mock", "fixture/chans.Bus"))
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/chans"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	var b chans.Bus = m
+	if b.Events() != nil || b.Sink() != nil {
+		t.Error("want nil channels")
+	}
+	if in, out := b.Stream(); in != nil || out != nil {
+		t.Error("want nil channels")
+	}
+	if b.Token() != struct{}{} {
+		t.Error("want struct{}{}")
+	}
+
+	events := make(chan struct{}, 1)
+	events <- struct{}{}
+	m.EventsFunc = func() <-chan struct{} { return events }
+	<-b.Events()
+	m.SubscribeFunc = func(ch chan<- *chans.Event, done <-chan struct{}) error {
+		ch <- &chans.Event{Name: "a"}
+		return nil
+	}
+	ch := make(chan *chans.Event, 1)
+	if err := b.Subscribe(ch, nil); err != nil || (<-ch).Name != "a" {
+		t.Errorf("Subscribe() = %v", err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestLocal(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/kv.Store"), "import (\n\t\"fixture/kv\"\n\t\"io\"\n)\n")
+	contains(t, g.gen("mock.go", "-local", "fixture", "Mock", "fixture/kv.Store"), "import (\n\t\"io\"\n\n\t\"fixture/kv\"\n)\n")
+	stdout, _, _ := g.run("-local", "fixture", "-pkg", "out", "Mock", "fixture/kv.Store")
+	contains(t, stdout, "import (\n\t\"io\"\n\n\t\"fixture/kv\"\n)\n")
+	g.vet()
+}
+
+func TestEmbeddedGeneric(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/sessions.Store"),
+		"func (t *Mock) Get(key string) (*sessions.Session, bool) {",
+		"func (t *Mock) Put(key string, v *sessions.Session, ttl time.Duration) {",
+		"func (t *Mock) List(filter func(sessions.Session) bool) []sessions.Session {",
+		"var _ sessions.Store = (*Mock)(nil)")
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/sessions"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var s sessions.Store = &Mock{GetFunc: func(key string) (*sessions.Session, bool) {
+		return &sessions.Session{User: key}, true
+	}}
+	if sess, ok := s.Get("u"); !ok || sess.User != "u" {
+		t.Errorf("Get() = %+v, %t", sess, ok)
+	}
+	if l := s.List(nil); len(l) != 0 {
+		t.Errorf("List() = %v", l)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestNoFormat(t *testing.T) {
+	g := newSandbox(t)
+	formatted := g.gen("mock.go", "-embed-directive=false", "-local", "fixture", "Mock", "fixture/kv.Store")
+	src := g.gen("mock.go", "-embed-directive=false", "-local", "fixture", "-noformat", "Mock", "fixture/kv.Store")
+	// gofmt sorts the imports, which goimports groups.
+	contains(t, src, "import (\n\t\"fixture/kv\"\n\t\"io\"\n)\n")
+	if want := strings.Replace(formatted, "\"io\"\n\n\t\"fixture/kv\"", "\"fixture/kv\"\n\t\"io\"", 1); src != want {
+		t.Errorf("-noformat output differs from goimports beyond grouping:\n%s", unifiedDiff("goimports", "noformat", []byte(want), []byte(src)))
+	}
+	if fmtd, err := format.Source([]byte(src)); err != nil || string(fmtd) != src {
+		t.Errorf("-noformat output isn't gofmt formatted: %v", err)
+	}
+	g.vet()
+
+	// Imports of types the code doesn't mention are removed.
+	src = g.gen("mock.go", "-noformat", "Mock", "fixture/cache.Cache")
+	if strings.Contains(src, "\"fixture/cache\"") {
+		t.Errorf("unused import in\n%s", src)
+	}
+	g.vet()
+}
+
+func TestGroupedTypes(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "Mock", "fixture/grouped.Sink"), "// Write logs e.\nfunc (t *Mock) Write(e grouped.Entry) error {")
+	g.write("mock_test.go", `package out
+
+import (
+	"fixture/grouped"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var got []grouped.Entry
+	var s grouped.Sink = &Mock{WriteFunc: func(e grouped.Entry) error {
+		got = append(got, e)
+		return nil
+	}}
+	s.Write(grouped.Entry{Level: grouped.Info, Msg: "hi"})
+	if len(got) != 1 || got[0].Msg != "hi" {
+		t.Errorf("got %+v", got)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	stdout, stderr, code := g.run("-pkg", "out", "-file", "../fixture/grouped/grouped.go", "-line", "20", "-recv", "Mock")
+	if code != 0 {
+		t.Fatalf("-file: exit %d\n%s", code, stderr)
+	}
+	contains(t, stdout, "func (t *Mock) Flush() (n int, err error) {")
+}
+
+func TestDump(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-capture", "Mock", "io.ReadWriteCloser"), "func (t *Mock) Dump() string {")
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	m.Write([]byte("a"))
+	m.Write([]byte("bc"))
+	m.Close()
+	want := "Mock:" +
+		"\n\tRead: 0 calls" +
+		"\n\tWrite: 2 calls, last with {P:[98 99]}" +
+		"\n\tClose: 1 calls"
+	if got := m.Dump(); got != want {
+		t.Errorf("Dump() = %q, want %q", got, want)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	// The Dump method of an interface isn't replaced.
+	g.write("mock_test.go", "package out\n")
+	src := g.gen("mock.go", "-capture", "Mock", "interface{ Dump() string }")
+	if strings.Count(src, "func (t *Mock) Dump() string {") != 1 || strings.Contains(src, "strings.Builder") {
+		t.Errorf("want only the Dump method of the interface in\n%s", src)
+	}
+	g.vet()
+}
+
+func TestSmartDefaults(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-smart-defaults", "Mock", "fixture/ctor.Dialer"),
+		"\treturn ctor.NewConn(), nil\n}\n",
+		"\treturn ctor.Default()\n}\n",
+		"\treturn ctor.Plain{}\n}\n")
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	if c, err := m.Dial(); c == nil || c.Addr != "localhost" || err != nil {
+		t.Errorf("Dial() = %+v, %v", c, err)
+	}
+	if o := m.Options(); o.Retries != 3 {
+		t.Errorf("Options() = %+v", o)
+	}
+	if p := m.Plain(); p.N != 0 {
+		t.Errorf("Plain() = %+v", p)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	// Without the flag, results default to their zero values.
+	contains(t, g.gen("mock.go", "Mock", "fixture/ctor.Dialer"), "\treturn nil, nil\n}\n", "\treturn ctor.Options{}\n}\n")
+}
+
+func TestNameTemplate(t *testing.T) {
+	g := newSandbox(t)
+	src := g.gen("mocks.go", "-pkg", "out", "-capture", "-name", "{{.Iface}}Mock", "io.ReadCloser,io.WriteCloser")
+	contains(t, src, "type ReadCloserMock struct {", "type WriteCloserMock struct {",
+		"type ReadCloserMockCloseCall struct {", "type WriteCloserMockCloseCall struct {",
+		" -name={{.Iface}}Mock -pkg=out -iface io.ReadCloser,io.WriteCloser -o mocks.go")
+	if n := strings.Count(src, "package out"); n != 1 {
+		t.Errorf("%d package clauses in\n%s", n, src)
+	}
+	g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	r, w := &ReadCloserMock{}, &WriteCloserMock{}
+	var _ io.ReadCloser = r
+	var _ io.WriteCloser = w
+	r.Close()
+	w.Write([]byte("x"))
+	w.Close()
+	w.Close()
+	if len(r.CloseCalls) != 1 || len(w.CloseCalls) != 2 || len(w.WriteCalls) != 1 {
+		t.Errorf("calls: %d, %d, %d", len(r.CloseCalls), len(w.CloseCalls), len(w.WriteCalls))
+	}
+	if got := r.Dump() + "\n" + w.Dump(); !strings.Contains(got, "ReadCloserMock:") || !strings.Contains(got, "WriteCloserMock:") {
+		t.Errorf("Dump() = %q", got)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	_, stderr, code := g.run("-name", "Mock", "io.Reader,io.Writer")
+	if code != exitUsage || !strings.Contains(stderr, "-name gives io.Reader and io.Writer the same receiver type Mock") {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	g := newSandbox(t)
+	for _, tt := range []struct{ iface, pos, msg string }{
+		{"fixture/broken.Bad", "broken.go:9:2: ", "Missing"},
+		{"fixture/broken.Struct", "broken.go:13:6: ", "not an interface: fixture/broken.Struct"},
+	} {
+		_, stderr, code := g.run("Mock", tt.iface)
+		if code == 0 || !strings.Contains(stderr, tt.pos) || !strings.Contains(stderr, tt.msg) {
+			t.Errorf("%s: exit %d, want %q and %q in\n%s", tt.iface, code, tt.pos, tt.msg, stderr)
+		}
+	}
+}
+
+func TestBareIdentifier(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-pkg", "out", "-dir", "../fixture/kv", "Mock", "Store"),
+		"\t\"fixture/kv\"\n", "var _ kv.Store = (*Mock)(nil)", "func (t *Mock) Open(key string) (io.ReadCloser, bool, error) {")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+
+	"fixture/kv"
+)
+
+func TestMock(t *testing.T) {
+	var s kv.Store = &Mock{GetFunc: func(key string) (string, error) { return key + "!", nil }}
+	if v, err := s.Get("k"); v != "k!" || err != nil {
+		t.Errorf("Get() = %q, %v", v, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	// The interface must be declared in the package of -dir.
+	if _, stderr, code := g.run("-pkg", "out", "-dir", "../fixture/kv", "Mock", "Reader"); code != exitNotFound {
+		t.Errorf("exit %d, want %d\n%s", code, exitNotFound, stderr)
+	}
+}
+
+func TestBlankNames(t *testing.T) {
+	for _, flags := range [][]string{
+		nil,
+		{"-capture", "-asserts", "-spy", "-queue", "-builder"},
+		{"-delegate"},
+		{"-embed-iface"},
+		{"-strict"},
+		{"-noformat"},
+		{"-style", "testify"},
+		{"-style", "gomock"},
+	} {
+		t.Run(strings.Join(flags, " "), func(t *testing.T) {
+			g := newSandbox(t)
+			contains(t, g.gen("mock.go", append(flags, "Mock", "fixture/blank.Blank")...), "func (t *Mock) Do() (_ int, err error) {")
+			g.vet()
+		})
+	}
+
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-capture", "Mock", "fixture/blank.Blank"), "DoFunc   func() (_ int, err error)", "func (t *Mock) Set(arg0 string, n int) {")
+	g.write("mock_test.go", `package out
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	want := errors.New("x")
+	m := &Mock{DoFunc: func() (int, error) { return 1, want }}
+	if n, err := m.Do(); n != 1 || err != want {
+		t.Errorf("Do() = %d, %v", n, err)
+	}
+	m.AllFunc = func(a, b int) bool { return a < b }
+	if !m.All(1, 2) || m.All(2, 1) {
+		t.Error("All() doesn't pass on its params")
+	}
+	m.Set("s", 1)
+	m.Var(1, 2)
+	if c := m.SetCalls[0]; c.Arg0 != "s" || c.N != 1 || len(m.VarCalls[0].Arg0) != 2 {
+		t.Errorf("calls %+v, %+v", m.SetCalls, m.VarCalls)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestCompileCheck(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-compile-check", "Mock", "io.Reader"), "func (t *Mock) Read(p []byte) (n int, err error) {")
+
+	// The defaults of another package may refer to its unexported
+	// declarations, which don't compile in the generated code.
+	g.write("../defaults/defaults.go", `package defaults
+
+const answer = 42
+
+var _ int = answer
+`)
+	_, stderr, code := g.run("-compile-check", "-defaults", "../defaults/defaults.go", "Mock", "io.Reader", "bad.go")
+	if code != exitParse || !strings.Contains(stderr, "generated code doesn't compile:") || !strings.Contains(stderr, "bad.go:") || !strings.Contains(stderr, "undefined: answer") {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(g.dir, "bad.go")); !os.IsNotExist(err) {
+		t.Errorf("bad.go was written: %v", err)
+	}
+	// Without the check, the code is written.
+	if _, stderr, code := g.run("-defaults", "../defaults/defaults.go", "Mock", "io.Reader", "bad.go"); code != 0 {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+}
+
+func TestShadowedImports(t *testing.T) {
+	g := newSandbox(t)
+	// The package of the mock may be named like the interface's.
+	if _, stderr, code := g.run("-capture", "Mock", "fixture/kv.Store", "../app/kv/mock.go"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("../app/kv/mock.go"), "package kv\n", "\t\"fixture/kv\"\n", "var _ kv.Store = (*Mock)(nil)")
+	g.write("../app/kv/mock_test.go", `package kv
+
+import (
+	"testing"
+
+	"fixture/kv"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	var s kv.Store = m
+	s.Put("k", []byte("v"))
+	if len(m.PutCalls) != 1 || m.PutCalls[0].Key != "k" {
+		t.Errorf("PutCalls = %+v", m.PutCalls)
+	}
+}
+`)
+	g.goCmd("test", "app/kv")
+
+	// Identifiers named like a package shadow it, so it is renamed.
+	contains(t, g.gen("mock.go", "io", "io.Reader"), "\tio2 \"io\"\n", "var _ io2.Reader = (*io)(nil)")
+	g.vet()
+	contains(t, g.gen("mock.go", "-rname", "kv", "Mock", "fixture/kv.Store"), "\tkv2 \"fixture/kv\"\n", "func (kv *Mock) Open(key string) (io.ReadCloser, bool, error) {")
+	g.vet()
+	contains(t, g.gen("mock.go", "Mock", "fixture/shadow.Timer"), "\ttime2 \"time\"\n", "func (t *Mock) After(time time2.Duration) time2.Time {")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	if got := m.After(time.Second); !got.IsZero() {
+		t.Errorf("After() = %v", got)
+	}
+	now := time.Now()
+	m.AfterFunc = func(d time.Duration) time.Time { return now.Add(d) }
+	if got := m.After(time.Second); !got.Equal(now.Add(time.Second)) {
+		t.Errorf("After() = %v", got)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestLintSuppress(t *testing.T) {
+	for _, flags := range [][]string{
+		nil,
+		{"-delegate"},
+		{"-style", "testify"},
+		{"-style", "gomock"},
+	} {
+		t.Run(strings.Join(flags, " "), func(t *testing.T) {
+			g := newSandbox(t)
+			src := g.gen("mock.go", append(flags, "-lint-suppress", "Mock", "io.ReadWriter")...)
+			contains(t, src, "//nolint:all\ntype Mock struct {", "//nolint:all\nfunc (t *Mock) Read(", "//nolint:all\nfunc (t *Mock) Write(")
+			g.vet()
+		})
+	}
+	g := newSandbox(t)
+	if src := g.gen("mock.go", "Mock", "io.ReadWriter"); strings.Contains(src, "nolint") {
+		t.Errorf("directive without -lint-suppress in\n%s", src)
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	g := newSandbox(t)
+	g.write("internal/svc/svc.go", `package svc
+
+type Service interface {
+	Do(name string) (int, error)
+}
+`)
+	contains(t, g.gen("mock.go", "-pkg", "out", "Mock", "./internal/svc.Service"), "\t\"out/internal/svc\"\n", "var _ svc.Service = (*Mock)(nil)")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+
+	"out/internal/svc"
+)
+
+func TestMock(t *testing.T) {
+	var s svc.Service = &Mock{DoFunc: func(name string) (int, error) { return len(name), nil }}
+	if n, err := s.Do("abc"); n != 3 || err != nil {
+		t.Errorf("Do() = %d, %v", n, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	contains(t, g.gen("mock.go", "-pkg", "out", "Mock", "../fixture/kv.Store"), "\t\"fixture/kv\"\n", "var _ kv.Store = (*Mock)(nil)")
+	for iface, want := range map[string]int{"./internal/missing.Service": exitNotFound, "./internal/svc": exitParse} {
+		if _, stderr, code := g.run("Mock", iface); code != want {
+			t.Errorf("%s: exit %d, want %d\n%s", iface, code, want, stderr)
+		}
+	}
+}
+
+func TestStringerError(t *testing.T) {
+	for _, flags := range [][]string{{"-capture"}, {"-style", "testify"}, {"-style", "gomock"}} {
+		t.Run(strings.Join(flags, " "), func(t *testing.T) {
+			g := newSandbox(t)
+			contains(t, g.gen("value.go", append(flags, "-compile-check", "Value", "fixture/describe.Value")...), "func (t *Value) String() string {")
+			contains(t, g.gen("failure.go", append(flags, "-compile-check", "Failure", "fixture/describe.Failure")...), "func (t *Failure) Error() string {")
+			g.vet()
+		})
+	}
+
+	g := newSandbox(t)
+	g.gen("value.go", "-capture", "Value", "fixture/describe.Value")
+	g.gen("failure.go", "-capture", "Failure", "fixture/describe.Failure")
+	g.write("mock_test.go", `package out
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	v := &Value{StringFunc: func() string { return "v" }}
+	if got := fmt.Sprint(v); got != "v" {
+		t.Errorf("Sprint() = %q", got)
+	}
+	if got := v.Dump(); !strings.Contains(got, "String: 1 calls") {
+		t.Errorf("Dump() = %q", got)
+	}
+	var err error = &Failure{ErrorFunc: func() string { return "failed" }}
+	var f *Failure
+	if !errors.As(err, &f) || err.Error() != "failed" || f.Temporary() {
+		t.Errorf("Error() = %q", err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestReturn(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-return", "Read=0,io.EOF", "-return", "Write=len(p), nil", "Mock", "io.ReadWriter"),
+		"\treturn 0, io.EOF\n}\n", "\treturn len(p), nil\n}\n", ` -return "Read=0, io.EOF" -return "Write=len(p), nil" `)
+	g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	if n, err := m.Read(make([]byte, 4)); n != 0 || err != io.EOF {
+		t.Errorf("Read() = %d, %v", n, err)
+	}
+	if n, err := m.Write([]byte("abc")); n != 3 || err != nil {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+	m.ReadFunc = func(p []byte) (int, error) { return 1, nil }
+	if n, err := m.Read(nil); n != 1 || err != nil {
+		t.Errorf("Read() = %d, %v", n, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	for _, tt := range []struct{ arg, msg string }{
+		{"Read=0", "-return Read: Read returns 2 results, got 1"},
+		{"Close=nil", "io.Reader has no method Close"},
+		{"Read", "expected name=results: Read"},
+	} {
+		_, stderr, code := g.run("-return", tt.arg, "Mock", "io.Reader")
+		if code != exitUsage || !strings.Contains(stderr, tt.msg) {
+			t.Errorf("-return %s: exit %d, want %q in\n%s", tt.arg, code, tt.msg, stderr)
+		}
+	}
+}
+
+// syncTest calls the methods of the mocks Coarse and Fine of
+// fixture/svc.Service, generated with -capture -queue and -sync coarse
+// and fine, concurrently.
+const syncTest = `package out
+
+import (
+	"context"
+	"fixture/svc"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// call calls the method i of m.
+func call(m svc.Service, i int) {
+	switch i % 5 {
+	case 0:
+		m.Get(context.Background(), "key")
+	case 1:
+		m.List("prefix", 1)
+	case 2:
+		m.Since()
+	case 3:
+		m.Stats()
+	case 4:
+		m.Close()
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	c, f := &Coarse{}, &Fine{}
+	for _, m := range []svc.Service{c, f} {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					call(m, i)
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+	if len(c.GetCalls) != 200 || len(f.ListCalls) != 200 || len(f.CloseCalls) != 200 {
+		t.Errorf("recorded %d, %d and %d calls, want 200", len(c.GetCalls), len(f.ListCalls), len(f.CloseCalls))
+	}
+}
+
+// BenchmarkSync calls Since, Stats and Close, whose calls take no memory to
+// record, in parallel.
+func BenchmarkSync(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		m    svc.Service
+	}{
+		{"coarse", &Coarse{}},
+		{"fine", &Fine{}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var n int32
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(atomic.AddInt32(&n, 1))%3 + 2
+				for pb.Next() {
+					call(bc.m, i)
+				}
+			})
+		})
+	}
+}
+`
+
+// syncSandbox returns a sandbox with the mocks and test of syncTest.
+func syncSandbox(tb testing.TB) *sandbox {
+	g := newSandbox(tb)
+	g.gen("coarse.go", "-sync", "coarse", "-capture", "-queue", "Coarse", "fixture/svc.Service")
+	g.gen("fine.go", "-sync", "fine", "-capture", "-queue", "Fine", "fixture/svc.Service")
+	g.write("sync_test.go", syncTest)
+	return g
+}
+
+func TestSync(t *testing.T) {
+	g := syncSandbox(t)
+	g.goCmd("test", "-race", "-bench", ".", "-benchtime", "100x", ".")
+}
+
+// BenchmarkSync runs the benchmark of syncTest, comparing -sync coarse and
+// fine, and logs its results.
+func BenchmarkSync(b *testing.B) {
+	g := syncSandbox(b)
+	for i := 0; i < b.N; i++ {
+		cmd := exec.Command("go", "test", "-run", "^$", "-bench", ".", "-cpu", "1,4,8", ".")
+		cmd.Dir, cmd.Env = g.dir, g.env()
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			b.Fatalf("go test: %v\n%s", err, out)
+		}
+		b.Log("\n" + string(out))
+	}
+}
+
+func TestUnexportedFields(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-compile-check", "Mock", "fixture/unexp.Source"), "\treturn unexp.Result{}, nil\n")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+
+	"fixture/unexp"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	if r, err := m.Fetch("a"); r != (unexp.Result{}) || r.Count() != 0 || err != nil {
+		t.Errorf("Fetch() = %+v, %v", r, err)
+	}
+	m.FetchFunc = func(name string) (unexp.Result, error) { return unexp.NewResult(name, 2), nil }
+	if r, _ := m.Fetch("a"); r.Name != "a" || r.Count() != 2 {
+		t.Errorf("Fetch() = %+v", r)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestWatch(t *testing.T) {
+	g := newSandbox(t)
+	g.write("../app/svc/svc.go", "package svc\n\ntype Service interface {\n\tGet() int\n}\n")
+	cmd := exec.Command(testgenBin, "-v", "-watch", "Mock", "app/svc.Service", "mock.go")
+	var stderr syncBuffer
+	cmd.Dir, cmd.Env, cmd.Stderr = g.dir, g.env(), &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	// waitFor waits until mock.go declares method.
+	waitFor := func(method string) {
+		t.Helper()
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			if src, _ := ioutil.ReadFile(filepath.Join(g.dir, "mock.go")); strings.Contains(string(src), "func (t *Mock) "+method+"(") {
+				return
+			}
+		}
+		t.Fatalf("mock.go doesn't declare %s\n%s", method, stderr.String())
+	}
+	waitFor("Get")
+
+	// Files saved together are regenerated once.
+	g.write("../app/svc/svc.go", "package svc\n\ntype Service interface {\n\tGet() int\n\tPut(v int)\n}\n")
+	g.write("../app/svc/del.go", "package svc\n\ntype Deleter interface {\n\tDel()\n}\n")
+	g.write("../app/svc/svc.go", "package svc\n\ntype Service interface {\n\tGet() int\n\tPut(v int)\n\tDeleter\n}\n")
+	waitFor("Del")
+	time.Sleep(time.Second)
+	if n := strings.Count(stderr.String(), "regenerating after changes"); n != 1 {
+		t.Errorf("regenerated %d times, want 1\n%s", n, stderr.String())
+	}
+	g.vet()
+
+	if _, stderr, code := g.run("-watch", "Mock", "io.Reader"); code != exitUsage || !strings.Contains(stderr, "-watch requires an output file") {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+}
+
+func TestRegenerateOn(t *testing.T) {
+	events, errs, runs := make(chan fsnotify.Event), make(chan error), make(chan bool, 10)
+	done := make(chan error)
+	skip := func(file string) bool { return file == "/svc/mock.go" }
+	go func() {
+		done <- regenerateOn(events, errs, skip, 50*time.Millisecond, func() { runs <- true })
+	}()
+	// Files saved together are regenerated once.
+	for _, name := range []string{"/svc/svc.go", "/svc/del.go", "/svc/svc.go"} {
+		events <- fsnotify.Event{Name: name, Op: fsnotify.Write}
+	}
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no regeneration after writes")
+	}
+	// Neither the output, tests, other files nor mode changes regenerate.
+	for _, ev := range []fsnotify.Event{
+		{Name: "/svc/mock.go", Op: fsnotify.Write},
+		{Name: "/svc/svc_test.go", Op: fsnotify.Create},
+		{Name: "/svc/.svc.go.swp", Op: fsnotify.Write},
+		{Name: "/svc/svc.go", Op: fsnotify.Chmod},
+	} {
+		events <- ev
+	}
+	select {
+	case <-runs:
+		t.Fatal("regenerated more than once")
+	case <-time.After(200 * time.Millisecond):
+	}
+	want := errors.New("watch failed")
+	errs <- want
+	if err := <-done; err != want {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDotImport(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-compile-check", "Mock", "fixture/dotimp.Log"),
+		"func (t *Mock) Read(p []byte) (n int, err error) {",
+		"func (t *Mock) Since(start time.Time) time.Duration {",
+		"\tf \"fmt\"\n", "func (t *Mock) Add(e dotimp.Event, s f.Stringer) []*dotimp.Event {",
+		"func (t *Mock) Tick() <-chan time.Time {")
+	g.write("mock_test.go", `package out
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"fixture/dotimp"
+)
+
+func TestMock(t *testing.T) {
+	var l dotimp.Log = &Mock{
+		SinceFunc: func(start time.Time) time.Duration { return time.Hour },
+		AddFunc: func(e dotimp.Event, s fmt.Stringer) []*dotimp.Event {
+			return []*dotimp.Event{&e, {Name: s.String()}}
+		},
+	}
+	if d := l.Since(time.Now()); d != time.Hour {
+		t.Errorf("Since() = %v", d)
+	}
+	if es := l.Add(dotimp.Event{Name: "a"}, time.Second); len(es) != 2 || es[1].Name != "1s" {
+		t.Errorf("Add() = %v", es)
+	}
+	if ch := l.Tick(); ch != nil {
+		t.Errorf("Tick() = %v", ch)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+// TestImports checks that types of dot imports are qualified by their
+// package, and that renamed imports keep their names.
+func TestImports(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-compile-check", "Mock", "fixture/imps.Imps"),
+		"func (t *Mock) Get(l other.Limit) (other.Value, error) {",
+		"func (t *Mock) Fetch(s f.Stringer) (fmt.Result, error) {",
+		"func (t *Mock) Loc() imps.Loc {")
+	g.write("mock_test.go", `package out
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"fixture/imps"
+	"fixture/imps/other"
+	"fixture/unexp"
+)
+
+func TestMock(t *testing.T) {
+	var i imps.Imps = &Mock{
+		GetFunc: func(l other.Limit) (other.Value, error) { return other.Value{N: int(l)}, nil },
+		FetchFunc: func(s fmt.Stringer) (unexp.Result, error) {
+			return unexp.NewResult(s.String(), 1), nil
+		},
+	}
+	if v, err := i.Get(3); v.N != 3 || err != nil {
+		t.Errorf("Get() = %+v, %v", v, err)
+	}
+	if r, err := i.Fetch(time.Second); r.Name != "1s" || err != nil {
+		t.Errorf("Fetch() = %+v, %v", r, err)
+	}
+	if l := i.Loc(); l != (imps.Loc{}) {
+		t.Errorf("Loc() = %+v", l)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestLog(t *testing.T) {
+	for _, flags := range [][]string{{"-capture"}, {"-delegate"}} {
+		t.Run(flags[0], func(t *testing.T) {
+			g := newSandbox(t)
+			contains(t, g.gen("mock.go", append(flags, "-log", "Mock", "fixture/kv.Store")...),
+				"// Logger logs the calls, or the standard logger if it is nil.\n\tLogger interface {\n",
+				"\tt.logf(\"Mock.Put(%+v, %+v)\", key, v)\n")
+			g.write("mock_test.go", `package out
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var buf bytes.Buffer
+	m := &Mock{Logger: log.New(&buf, "", 0)}
+	m.Put("k", []byte("hi"))
+	m.Reset()
+	if got, want := buf.String(), "Mock.Put(k, [104 105])\nMock.Reset()\n"; got != want {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+
+	// Without a Logger, calls go to the standard logger.
+	buf.Reset()
+	log.SetFlags(0)
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	m.Logger = nil
+	m.Get("k")
+	if got, want := buf.String(), "Mock.Get(k)\n"; got != want {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+}
+`)
+			g.goCmd("test", ".")
+		})
+	}
+
+	g := newSandbox(t)
+	_, stderr, code := g.run("-log", "Mock", "interface{ Logger() }")
+	if code != exitParse || !strings.Contains(stderr, "Config.Log: interface{ Logger() } has a method Logger") {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+}
+
+func TestStub(t *testing.T) {
+	g := newSandbox(t)
+	golden(t, "stub", g.gen("stub.go", "-style", "stub", "Sorter", "sort.Interface"))
+	g.write("sorter.go", "package out\n\ntype Sorter []int\n")
+	g.write("stub_test.go", `package out
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestStub(t *testing.T) {
+	var s sort.Interface = &Sorter{}
+	defer func() {
+		if r := recover(); r != "not implemented" {
+			t.Errorf("recovered %v", r)
+		}
+	}()
+	s.Len()
+}
+`)
+	g.goCmd("test", ".")
+
+	// Stubs aren't generated files, so they aren't overwritten.
+	if _, stderr, code := g.run("-style", "stub", "Sorter", "sort.Interface", "stub.go"); code == 0 || !strings.Contains(stderr, "stub.go") {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+	// -missing stubs the methods the type lacks.
+	g.write("stub.go", "package out\n\nfunc (s *Sorter) Len() int { return len(*s) }\n")
+	src := g.gen("more.go", "-style", "stub", "-missing", "Sorter", "sort.Interface")
+	contains(t, src, "func (t *Sorter) Less(i int, j int) bool {", "func (t *Sorter) Swap(i int, j int) {")
+	if strings.Contains(src, "Len()") {
+		t.Errorf("Len stubbed again in\n%s", src)
+	}
+	g.vet()
+}
+
+func TestZeroHelper(t *testing.T) {
+	g := newSandbox(t)
+	contains(t, g.gen("mock.go", "-zero-helper", "-compile-check", "Mock", "fixture/shapes.Shapes"),
+		"func zeroMock[T any]() T {",
+		"\treturn zeroMock[shapes.Point]()\n",
+		"\treturn zeroMock[struct{ N int }]()\n",
+		"\treturn zeroMock[[2]shapes.Point]()\n",
+		"\treturn zeroMock[shapes.Level]()\n",
+		"\treturn zeroMock[time.Time](), nil\n",
+		"\treturn nil\n}\n\n// Map ")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+	"time"
+
+	"fixture/shapes"
+)
+
+func TestMock(t *testing.T) {
+	var s shapes.Shapes = &Mock{}
+	if s.Chan() != nil || s.Map() != nil || s.Iface() != nil || s.Empty() != nil {
+		t.Error("want nil chan, map and interfaces")
+	}
+	if p := s.Struct(); p != (shapes.Point{}) {
+		t.Errorf("Struct() = %+v", p)
+	}
+	if a := s.Anon(); a.N != 0 {
+		t.Errorf("Anon() = %+v", a)
+	}
+	if a := s.Array(); a != ([2]shapes.Point{}) {
+		t.Errorf("Array() = %+v", a)
+	}
+	if l := s.Named(); l != 0 {
+		t.Errorf("Named() = %d", l)
+	}
+	if tm, err := s.Time(); !tm.Equal(time.Time{}) || err != nil {
+		t.Errorf("Time() = %v, %v", tm, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	// Mocks whose results don't need it don't declare it, and mocks in
+	// one package each declare their own.
+	if src := g.gen("reader.go", "-zero-helper", "Reader", "io.Reader"); strings.Contains(src, "zeroReader") {
+		t.Errorf("zeroReader declared in\n%s", src)
+	}
+	contains(t, g.gen("other.go", "-zero-helper", "Other", "fixture/shapes.Shapes"), "func zeroOther[T any]() T {")
+	g.vet()
+}
+
+func TestSpecFile(t *testing.T) {
+	g := newSandbox(t)
+	g.write("mocks/specs.txt", "# mocks of the sandbox\nReader=io.Reader\n\nStore = fixture/kv.Store\nClock=fixture/clock.Clock\n")
+	if _, stderr, code := g.run("-capture", "-spec-file", "mocks/specs.txt", "-spec", "Closer=io.Closer", "-o", "mocks"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	for recv, iface := range map[string]string{"Reader": "io.Reader", "Store": "fixture/kv.Store", "Clock": "fixture/clock.Clock", "Closer": "io.Closer"} {
+		file := "mock_" + strings.ToLower(recv) + ".go"
+		contains(t, g.read("mocks/"+file), "package mocks\n",
+			"//go:generate testgen -capture=true -recv "+recv+" -iface "+iface+" -o "+file+"\n",
+			"type "+recv+" struct {")
+	}
+	g.write("mocks/mocks_test.go", `package mocks
+
+import (
+	"io"
+	"testing"
+
+	"fixture/clock"
+	"fixture/kv"
+)
+
+func TestMocks(t *testing.T) {
+	var (
+		_ io.Reader   = &Reader{}
+		_ io.Closer   = &Closer{}
+		_ kv.Store    = &Store{}
+		_ clock.Clock = &Clock{}
+	)
+	s := &Store{}
+	s.Put("k", nil)
+	if len(s.PutCalls) != 1 {
+		t.Errorf("PutCalls = %+v", s.PutCalls)
+	}
+}
+`)
+	g.goCmd("test", "./mocks")
+
+	for _, tt := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"-spec", "Reader=io.Reader", "-spec", "reader=io.Reader", "-o", "mocks"}, "-spec writes mock_reader.go twice"},
+		{[]string{"-spec", "Reader", "-o", "mocks"}, "expected recv=iface: Reader"},
+		{[]string{"-spec", "Reader=io.Reader", "-o", "mocks/specs.txt"}, "-spec and -pkg-all require -o to be a directory"},
+		{[]string{"-spec-file", "mocks/missing.txt", "-o", "mocks"}, "missing.txt"},
+	} {
+		if _, stderr, code := g.run(tt.args...); code != exitUsage || !strings.Contains(stderr, tt.msg) {
+			t.Errorf("%q: exit %d, want %q in\n%s", tt.args, code, tt.msg, stderr)
+		}
+	}
+}
+
+func TestInternal(t *testing.T) {
+	g := newSandbox(t)
+	g.write("../app/internal/svc/svc.go", `package svc
+
+type Service interface {
+	Do(name string) (int, error)
+}
+
+type Token struct{}
+
+type Issuer interface {
+	Issue() Token
+}
+`)
+	// The tree of the internal package can import it.
+	_, stderr, code := g.run("Mock", "app/internal/svc.Service", "../app/mocks/mock.go")
+	if code != 0 || strings.Contains(stderr, "warning") {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("../app/mocks/mock.go"), "var _ svc.Service = (*Mock)(nil)")
+
+	// Other packages can't, so the assertion is left out with a warning.
+	_, stderr, code = g.run("-compile-check", "Mock", "app/internal/svc.Service", "mock.go")
+	if code != 0 || !strings.Contains(stderr, "testgen: warning: out cannot import the internal package app/internal/svc, so the var _ assertion is left out") {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	if src := g.read("mock.go"); strings.Contains(src, "var _") || strings.Contains(src, `"app/internal/svc"`) {
+		t.Errorf("mock.go refers to the internal package\n%s", src)
+	}
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	m := &Mock{DoFunc: func(name string) (int, error) { return len(name), nil }}
+	if n, err := m.Do("abc"); n != 3 || err != nil {
+		t.Errorf("Do() = %d, %v", n, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	// Mocks that need the internal types fail.
+	for _, tt := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"Mock", "app/internal/svc.Issuer", "issuer.go"}, "method Issue of svc.Issuer uses a type of the internal package app/internal/svc, which out cannot import"},
+		{[]string{"-spy", "Mock", "app/internal/svc.Service", "spy.go"}, "app/internal/svc is internal to another tree than out"},
+	} {
+		if _, stderr, code := g.run(tt.args...); code == 0 || !strings.Contains(stderr, tt.msg) {
+			t.Errorf("%q: exit %d, want %q in\n%s", tt.args, code, tt.msg, stderr)
+		}
+	}
+}
+
+func TestArrays(t *testing.T) {
+	for _, flags := range [][]string{nil, {"-zero-helper"}} {
+		g := newSandbox(t)
+		src := g.gen("mock.go", append(flags, "-compile-check", "Mock", "fixture/arrays.Arrays")...)
+		if flags == nil {
+			contains(t, src, "\treturn [3]io.Reader{}\n", "\treturn [2][]int{}\n", "\treturn nil\n}\n\n// Grid ", "\treturn nil\n}\n\n// Ptr ")
+		}
+		g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{}
+	if r := m.Readers(); r != ([3]io.Reader{}) {
+		t.Errorf("Readers() = %v", r)
+	}
+	if b := m.Buffers(); b != nil {
+		t.Errorf("Buffers() = %v, want nil", b)
+	}
+	if g := m.Grid(); g[0] != nil || g[1] != nil {
+		t.Errorf("Grid() = %v", g)
+	}
+	if s := m.Sorted(); s != nil {
+		t.Errorf("Sorted() = %v, want nil", s)
+	}
+	if p := m.Ptr(); p != nil {
+		t.Errorf("Ptr() = %v, want nil", p)
+	}
+}
+`)
+		g.goCmd("test", ".")
+	}
+}
+
+func TestSubcommands(t *testing.T) {
+	g := newSandbox(t)
+	// mock and the legacy form generate the same mock.
+	legacy := g.gen("mock.go", "-capture", "Mock", "io.Reader")
+	if _, stderr, code := g.run("mock", "-capture", "Mock", "io.Reader", "mock.go"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	if got := g.read("mock.go"); got != legacy {
+		t.Errorf("mock generated\n%s\nwant\n%s", got, legacy)
+	}
+
+	if _, stderr, code := g.run("stub", "Sorter", "sort.Interface", "stub.go"); code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	contains(t, g.read("stub.go"), "func (t *Sorter) Len() int {\n\tpanic(\"not implemented\") // TODO: Implement\n}\n")
+
+	for _, args := range [][]string{{"list", "io.ReadWriteCloser"}, {"list", "Mock", "io.ReadWriteCloser"}} {
+		stdout, stderr, code := g.run(args...)
+		if want := "Read(p []byte) (n int, err error)\nWrite(p []byte) (n int, err error)\nClose() error\n"; code != 0 || stdout != want {
+			t.Errorf("%q: exit %d, got\n%s\nwant\n%s%s", args, code, stdout, want, stderr)
+		}
+	}
+
+	stdout, stderr, code := g.run("json", "fixture/logger.Logger")
+	var got ifaceJSON
+	if err := json.Unmarshal([]byte(stdout), &got); code != 0 || err != nil || got.Name != "Logger" || len(got.Methods) != 1 {
+		t.Errorf("json: exit %d, %v in\n%s%s", code, err, stdout, stderr)
+	}
+
+	// A subcommand must be the first argument.
+	if _, stderr, code := g.run("-capture", "stub", "Sorter", "sort.Interface"); code == 0 {
+		t.Errorf("stub after a flag: exit 0\n%s", stderr)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	g := newSandbox(t)
+	src := g.gen("getter.go", "-match", "Get.*", "Getter", "fixture/crud.Repo")
+	contains(t, src, "func (t *Getter) Get(id int) (crud.Item, error) {", "func (t *Getter) GetAll() ([]crud.Item, error) {")
+	for _, m := range []string{"Create", "Update", "Delete"} {
+		if strings.Contains(src, ") "+m+"(") {
+			t.Errorf("%s declared in\n%s", m, src)
+		}
+	}
+	src = g.gen("writer.go", "-skip-match", "^Get", "-skip", "Delete", "Writer", "fixture/crud.Repo")
+	contains(t, src, "func (t *Writer) Create(it crud.Item) error {", "func (t *Writer) Update(it crud.Item) error {")
+	if strings.Contains(src, ") Get") || strings.Contains(src, ") Delete(") {
+		t.Errorf("Get or Delete declared in\n%s", src)
+	}
+	contains(t, g.gen("one.go", "-match", "Get", "-skip-match", "All$", "One", "fixture/crud.Repo"), "func (t *One) Get(id int) (crud.Item, error) {")
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+
+	"fixture/crud"
+)
+
+func TestMock(t *testing.T) {
+	g := &Getter{GetFunc: func(id int) (crud.Item, error) { return crud.Item{ID: id}, nil }}
+	if it, err := g.Get(2); it.ID != 2 || err != nil {
+		t.Errorf("Get() = %+v, %v", it, err)
+	}
+	if its, err := g.GetAll(); its != nil || err != nil {
+		t.Errorf("GetAll() = %+v, %v", its, err)
+	}
+	if err := (&Writer{}).Create(crud.Item{}); err != nil {
+		t.Errorf("Create() = %v", err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	for _, tc := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"-match", "Get(", "Mock", "fixture/crud.Repo"}, "invalid -match: error parsing regexp"},
+		{[]string{"-skip-match", "*", "Mock", "fixture/crud.Repo"}, "invalid -skip-match: error parsing regexp"},
+		{[]string{"-match", "^List", "Mock", "fixture/crud.Repo"}, "no method of fixture/crud.Repo selected"},
+	} {
+		if _, stderr, code := g.run(tc.args...); code != exitUsage || !strings.Contains(stderr, tc.msg) {
+			t.Errorf("%q: exit %d, want %q in\n%s", tc.args, code, tc.msg, stderr)
+		}
+	}
+}
+
+func TestNestedTypes(t *testing.T) {
+	g := newSandbox(t)
+	src := g.gen("mock.go", "Mock", "fixture/nest.Nested")
+	for _, field := range []string{
+		"AFunc func(p *[]map[string]*nest.T) map[*nest.T][]*[2]nest.T",
+		"BFunc func(p []map[nest.T]*[]io.Reader, f func(*nest.T) []*nest.T) chan<- *[]map[string]nest.T",
+		"CFunc func(x ...*[]map[string]*nest.T) (*[]*map[string][]nest.T, error)",
+		"DFunc func(m map[string]map[io.Reader][]func(...*nest.T) error) [][]*io.Reader",
+	} {
+		if !strings.Contains(src, "\t"+field+"\n") {
+			t.Errorf("missing field %s in\n%s", field, src)
+		}
+	}
+	g.write("mock_test.go", `package out
+
+import (
+	"testing"
+
+	"fixture/nest"
+)
+
+func TestMock(t *testing.T) {
+	m := &Mock{AFunc: func(p *[]map[string]*nest.T) map[*nest.T][]*[2]nest.T {
+		return map[*nest.T][]*[2]nest.T{(*p)[0]["k"]: nil}
+	}}
+	var n nest.Nested = m
+	if got := n.A(&[]map[string]*nest.T{{"k": {}}}); len(got) != 1 {
+		t.Errorf("A() = %v", got)
+	}
+	if got, err := n.C(nil, nil); got != nil || err != nil {
+		t.Errorf("C() = %v, %v", got, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+// TestTypeParams checks that type parameters are kept unqualified in nested
+// positions, though the package declares a type K, and that type arguments
+// are substituted for them.
+func TestTypeParams(t *testing.T) {
+	for _, tc := range []struct {
+		iface string
+		want  []string
+	}{
+		{"fixture/gent.Mapper", []string{
+			"func (t *Mock[K, V]) Transform(f func(K) V) map[K]V {",
+			"func (t *Mock[K, V]) Each(fs []func(K, *V) error, m map[K][]*V) (chan V, []gent.Item) {",
+			"func (t *Mock[K, V]) Pair(k *K) (K, V) {",
+		}},
+		{"fixture/gent.Embeds", []string{
+			"func (t *Mock) Transform(f func(string) gent.Item) map[string]gent.Item {",
+			"func (t *Mock) Each(fs []func(string, *gent.Item) error, m map[string][]*gent.Item) (chan gent.Item, []gent.Item) {",
+			"func (t *Mock) Pair(k *string) (string, gent.Item) {",
+		}},
+	} {
+		for _, flags := range [][]string{nil, {"-capture", "-queue", "-builder", "-spy", "-zero-helper", "-strict"}} {
+			t.Run(tc.iface+" "+strings.Join(flags, " "), func(t *testing.T) {
+				g := newSandbox(t)
+				contains(t, g.gen("mock.go", append(flags, "Mock", tc.iface)...), tc.want...)
+				g.vet()
+			})
+		}
+	}
+
+	g := newSandbox(t)
+	g.gen("mock.go", "-capture", "Mock", "fixture/gent.Mapper")
+	g.write("mock_test.go", `package out
+
+import (
+	"strconv"
+	"testing"
+
+	"fixture/gent"
+)
+
+func TestMock(t *testing.T) {
+	var m gent.Mapper[int, string] = &Mock[int, string]{
+		TransformFunc: func(f func(int) string) map[int]string { return map[int]string{1: f(1)} },
+	}
+	if got := m.Transform(strconv.Itoa); got[1] != "1" {
+		t.Errorf("Transform() = %v", got)
+	}
+	if k, v := m.Pair(nil); k != 0 || v != "" {
+		t.Errorf("Pair() = %v, %q", k, v)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
+
+func TestNoGenHeader(t *testing.T) {
+	g := newSandbox(t)
+	src := g.gen("mock.go", "-no-gen-header", "-header", "// Code generated by hand; DO NOT EDIT.", "Mock", "io.Reader")
+	if !strings.HasPrefix(src, "package out\n") || strings.Contains(src, "DO NOT EDIT") || strings.Contains(src, "go:generate") {
+		t.Errorf("want no header in\n%s", src)
+	}
+	g.write("mock_test.go", `package out
+
+import (
+	"io"
+	"testing"
+)
+
+func TestMock(t *testing.T) {
+	var r io.Reader = &Mock{}
+	if n, err := r.Read(nil); n != 0 || err != nil {
+		t.Errorf("Read() = %d, %v", n, err)
+	}
+}
+`)
+	g.goCmd("test", ".")
+
+	// The output isn't a generated file, so it is only overwritten with
+	// -force.
+	if _, stderr, code := g.run("-no-gen-header", "Mock", "io.Reader", "mock.go"); code == 0 || !strings.Contains(stderr, "mock.go") {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+	g.gen("mock.go", "-no-gen-header", "-force", "Mock", "io.Reader")
+	// An explicit -embed-directive keeps the directive.
+	contains(t, g.gen("other.go", "-no-gen-header", "-embed-directive", "Other", "io.Reader"), "//go:generate testgen -no-gen-header=true -recv Other -iface io.Reader -o other.go\n")
+}
+
+func TestPkgAll(t *testing.T) {
+	g := newSandbox(t)
+	if err := os.Mkdir(filepath.Join(g.dir, "mocks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, code := g.run("-v", "-capture", "-pkg-all", "fixture/multi", "-o", "mocks")
+	if code != 0 {
+		t.Fatalf("exit %d\n%s", code, stderr)
+	}
+	for _, want := range []string{"skipping fixture/multi.Sealed: ", "skipping fixture/multi.Entries: "} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("missing %q in\n%s", want, stderr)
+		}
+	}
+	files, err := ioutil.ReadDir(filepath.Join(g.dir, "mocks"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fi := range files {
+		names = append(names, fi.Name())
+	}
+	if want := []string{"mock_markermock.go", "mock_sinkmock.go", "mock_sourcemock.go"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got files %q, want %q", names, want)
+	}
+	contains(t, g.read("mocks/mock_sinkmock.go"), "package mocks\n", "type SinkMock struct {", "var _ multi.Sink = (*SinkMock)(nil)")
+	g.write("mocks/mocks_test.go", `package mocks
+
+import (
+	"testing"
+
+	"fixture/multi"
+)
+
+func TestMocks(t *testing.T) {
+	var (
+		_ multi.Marker = MarkerMock{}
+		_ multi.Source = &SourceMock{}
+	)
+	s := &SinkMock{}
+	var sink multi.Sink = s
+	sink.Write([]byte("x"))
+	sink.Flush()
+	if len(s.WriteCalls) != 1 || len(s.FlushCalls) != 1 {
+		t.Errorf("calls %+v, %+v", s.WriteCalls, s.FlushCalls)
+	}
+}
+`)
+	g.goCmd("test", "./mocks")
+
+	g.write("empty/empty.go", "package empty\n\ntype T struct{}\n")
+	if _, stderr, code := g.run("-pkg-all", "./empty", "-o", "mocks"); code != exitNotFound || !strings.Contains(stderr, "no interfaces found in out/empty") {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+	g.write("sealed/sealed.go", "package sealed\n\ntype S interface{ s() }\n")
+	if _, stderr, code := g.run("-pkg-all", "./sealed", "-o", "mocks"); code != exitNotFound || !strings.Contains(stderr, "no mockable interfaces found in ./sealed") {
+		t.Errorf("exit %d\n%s", code, stderr)
+	}
+}
+
+func TestRecvNameClash(t *testing.T) {
+	for _, flags := range [][]string{
+		nil,
+		{"-capture", "-asserts", "-queue", "-builder", "-log"},
+		{"-spy"},
+		{"-delegate"},
+	} {
+		t.Run(strings.Join(flags, " "), func(t *testing.T) {
+			g := newSandbox(t)
+			src := g.gen("mock.go", append(flags, "Mock", "fixture/rn.Runner")...)
+			if !strings.Contains(src, "func (t *Mock) Run(arg0 *testing.T)") {
+				t.Errorf("param t of Run not renamed:\n%s", src)
+			}
+			g.vet()
+		})
+	}
+
+	g := newSandbox(t)
+	g.gen("mock.go", "-capture", "-pkg", "out", "Mock", "fixture/rn.Runner")
+	g.write("mock_test.go", `package out
+
+import "testing"
+
+func TestMock(t *testing.T) {
+	m := &Mock{
+		GetFunc:  func() (int, error) { return 7, nil },
+		BothFunc: func(s string, n int) bool { return len(s) == n },
+	}
+	m.Run(t)
+	if len(m.RunCalls) != 1 || m.RunCalls[0].Arg0 != t {
+		t.Errorf("RunCalls = %+v", m.RunCalls)
+	}
+	if n, err := m.Get(); n != 7 || err != nil {
+		t.Errorf("Get() = %d, %v", n, err)
+	}
+	if !m.Both("ab", 2) || m.BothCalls[0].Arg1 != "ab" || m.BothCalls[0].Arg0 != 2 {
+		t.Errorf("BothCalls = %+v", m.BothCalls)
+	}
+	if err := m.Anon("x", 1); err != nil || m.AnonCalls[0] != (MockAnonCall{"x", 1}) {
+		t.Errorf("Anon = %v, calls %+v", err, m.AnonCalls)
+	}
+}
+`)
+	g.goCmd("test", ".")
+}
@@ -0,0 +1,9 @@
+// Package app vendors github.com/dep/pkg.
+package app
+
+import "github.com/dep/pkg"
+
+// Dispatch passes an event named name to h.
+func Dispatch(h pkg.Handler, name string) error {
+	return h.Handle(&pkg.Event{Name: name})
+}
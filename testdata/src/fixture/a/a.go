@@ -0,0 +1,7 @@
+package a
+
+import "fixture/a/config"
+
+type Getter interface {
+	Get() config.Options
+}
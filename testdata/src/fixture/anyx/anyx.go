@@ -0,0 +1,9 @@
+// Package anyx declares an interface using any and interface literals.
+package anyx
+
+type Bag interface {
+	Get(key string) any
+	Log(args ...any)
+	Raw() interface{}
+	Closer() interface{ Close() error }
+}
@@ -0,0 +1,16 @@
+// Package arrays declares an interface returning arrays and slices.
+package arrays
+
+import (
+	"bytes"
+	"io"
+	"sort"
+)
+
+type Arrays interface {
+	Readers() [3]io.Reader
+	Buffers() []*bytes.Buffer
+	Grid() [2][]int
+	Sorted() sort.IntSlice
+	Ptr() *[2]int
+}
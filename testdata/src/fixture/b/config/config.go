@@ -0,0 +1,3 @@
+package config
+
+type Options struct{ B int }
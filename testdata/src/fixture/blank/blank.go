@@ -0,0 +1,9 @@
+// Package blank declares an interface with blank-named params and results.
+package blank
+
+type Blank interface {
+	Do() (_ int, err error)
+	Set(_ string, n int)
+	All(_, _ int) (_ bool)
+	Var(_ ...int)
+}
@@ -0,0 +1,13 @@
+// Package broken declares an interface embedding an undeclared one.
+package broken
+
+import "io"
+
+// Bad embeds Missing, which isn't declared.
+type Bad interface {
+	io.Reader
+	Missing
+}
+
+// Struct is not an interface.
+type Struct struct{}
@@ -0,0 +1,19 @@
+// Package cache declares generic interfaces with comparable, union and
+// custom constraints.
+package cache
+
+import "time"
+
+type Number interface {
+	~int | ~float64
+}
+
+type Cache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Put(key K, v V, ttl time.Duration)
+	Keys() []K
+}
+
+type Counter[K comparable, N Number] interface {
+	Add(key K, n N) N
+}
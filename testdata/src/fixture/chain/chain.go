@@ -0,0 +1,8 @@
+// Package chain declares an interface returning itself.
+package chain
+
+type Chain interface {
+	Next() Chain
+	Or(c Chain) (Chain, error)
+	Wrap() (Chain)
+}
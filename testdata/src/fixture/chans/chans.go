@@ -0,0 +1,13 @@
+// Package chans declares an interface with directional channels and empty
+// structs in params and results.
+package chans
+
+type Event struct{ Name string }
+
+type Bus interface {
+	Events() <-chan struct{}
+	Sink() chan<- int
+	Subscribe(ch chan<- *Event, done <-chan struct{}) error
+	Stream() (<-chan Event, chan<- Event)
+	Token() struct{}
+}
@@ -0,0 +1,17 @@
+// Package clock declares an interface with context and time results.
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+type Clock interface {
+	Context() context.Context
+	Timeout() time.Duration
+	Now() time.Time
+}
+
+type Scope interface {
+	WithCancel() (context.Context, context.CancelFunc)
+}
@@ -0,0 +1,8 @@
+// Package clone declares an interface with a method returning the
+// interface itself.
+package clone
+
+type Iface interface {
+	Clone() Iface
+	Len() int
+}
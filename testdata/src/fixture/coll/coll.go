@@ -0,0 +1,21 @@
+// Package coll declares an interface using instantiated generic types.
+package coll
+
+type List[T any] struct {
+	Items []T
+}
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Val V
+}
+
+type Getter[T any] interface {
+	Get() T
+}
+
+type Repo interface {
+	List() (*List[int], error)
+	Pair() Pair[string, []int]
+	Source() Getter[int]
+}
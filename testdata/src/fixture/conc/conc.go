@@ -0,0 +1,14 @@
+// Package conc declares a concrete type with value and pointer receiver
+// methods.
+package conc
+
+import "io"
+
+type Client struct{ addr string }
+
+// Addr returns the address of the client.
+func (c Client) Addr() string { return c.addr }
+
+func (c *Client) Send(w io.Writer, msg []byte) (int, error) { return w.Write(msg) }
+
+func (c *Client) reset() { c.addr = "" }
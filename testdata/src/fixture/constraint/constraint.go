@@ -0,0 +1,19 @@
+// Package constraint declares type constraints, which can't be
+// implemented.
+package constraint
+
+import "time"
+
+type Key interface {
+	~int | ~string
+}
+
+type Ordered interface {
+	comparable
+	Less(other int) bool
+}
+
+type Clock interface {
+	time.Duration
+	Now() time.Time
+}
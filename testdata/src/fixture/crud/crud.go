@@ -0,0 +1,15 @@
+// Package crud declares a CRUD-style interface.
+package crud
+
+type Item struct {
+	ID   int
+	Name string
+}
+
+type Repo interface {
+	Get(id int) (Item, error)
+	GetAll() ([]Item, error)
+	Create(it Item) error
+	Update(it Item) error
+	Delete(id int) error
+}
@@ -0,0 +1,27 @@
+// Package ctor declares types with and without constructors.
+package ctor
+
+// Conn is constructed by NewConn.
+type Conn struct{ Addr string }
+
+// NewConn returns a Conn to the default address.
+func NewConn() *Conn { return &Conn{Addr: "localhost"} }
+
+// Options are constructed by Default.
+type Options struct{ Retries int }
+
+// Default returns the default Options.
+func Default() Options { return Options{Retries: 3} }
+
+// Plain has no constructor.
+type Plain struct{ N int }
+
+// NewPlain takes an argument, so it isn't a constructor.
+func NewPlain(n int) Plain { return Plain{n} }
+
+// Dialer returns all of them.
+type Dialer interface {
+	Dial() (*Conn, error)
+	Options() Options
+	Plain() Plain
+}
@@ -0,0 +1,14 @@
+// Package describe declares interfaces embedding fmt.Stringer and error.
+package describe
+
+import "fmt"
+
+type Value interface {
+	fmt.Stringer
+	Set(s string) error
+}
+
+type Failure interface {
+	error
+	Temporary() bool
+}
@@ -0,0 +1,11 @@
+// Package doc declares an interface with documented methods.
+package doc
+
+type Store interface {
+	// Get returns the value of key.
+	//
+	// It returns false if key is not set.
+	Get(key string) (string, bool)
+	Put(key, value string) error // Put sets key to value.
+	Len() int
+}
@@ -0,0 +1,18 @@
+// Package dotimp declares an interface using dot-imported and renamed
+// packages.
+package dotimp
+
+import (
+	f "fmt"
+	. "io"
+	. "time"
+)
+
+type Event struct{ Name string }
+
+type Log interface {
+	Reader
+	Since(start Time) Duration
+	Add(e Event, s f.Stringer) []*Event
+	Tick() <-chan Time
+}
@@ -0,0 +1,6 @@
+// Package generic declares a generic interface.
+package generic
+
+type Getter[T any] interface {
+	Get() T
+}
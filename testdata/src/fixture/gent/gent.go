@@ -0,0 +1,18 @@
+// Package gent declares a generic interface using its type parameters in
+// nested positions, next to a type K of the package, and an interface
+// instantiating it.
+package gent
+
+type K struct{}
+
+type Item struct{}
+
+type Mapper[K comparable, V any] interface {
+	Transform(f func(K) V) map[K]V
+	Each(fs []func(K, *V) error, m map[K][]*V) (chan V, []Item)
+	Pair(k *K) (K, V)
+}
+
+type Embeds interface {
+	Mapper[string, Item]
+}
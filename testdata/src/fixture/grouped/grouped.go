@@ -0,0 +1,27 @@
+// Package grouped declares an interface among other types and consts in a
+// grouped type block.
+package grouped
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+)
+
+type (
+	// Entry is logged.
+	Entry struct {
+		Level Level
+		Msg   string
+	}
+
+	// Sink receives entries.
+	Sink interface {
+		// Write logs e.
+		Write(e Entry) error
+		Flush() (n int, err error)
+	}
+
+	Levels []Level
+)
@@ -0,0 +1,8 @@
+// Package hidden declares an interface using an unexported type.
+package hidden
+
+type request struct{}
+
+type Handler interface {
+	Handle(r request) error
+}
@@ -0,0 +1,17 @@
+// Package imps declares an interface using dot-imported and
+// renamed-imported types next to its own.
+package imps
+
+import (
+	. "fixture/imps/other"
+	fmt "fixture/unexp"
+	f "fmt"
+)
+
+type Loc struct{}
+
+type Imps interface {
+	Get(l Limit) (Value, error)
+	Fetch(s f.Stringer) (fmt.Result, error)
+	Loc() Loc
+}
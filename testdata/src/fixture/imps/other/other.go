@@ -0,0 +1,6 @@
+// Package other declares the types fixture/imps dot-imports.
+package other
+
+type Value struct{ N int }
+
+type Limit int
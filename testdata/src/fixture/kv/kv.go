@@ -0,0 +1,13 @@
+// Package kv declares an interface with variadic, error and several
+// results.
+package kv
+
+import "io"
+
+type Store interface {
+	Get(key string) (string, error)
+	Put(key string, v []byte) error
+	Each(prefix string, fs ...func(string) bool) (n int, err error)
+	Open(key string) (io.ReadCloser, bool, error)
+	Reset()
+}
@@ -0,0 +1,10 @@
+// Package list declares an interface returning a pointer to a struct.
+package list
+
+type Node struct {
+	Value int
+}
+
+type List interface {
+	Next() *Node
+}
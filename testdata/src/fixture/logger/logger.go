@@ -0,0 +1,6 @@
+// Package logger declares an interface with named and variadic params.
+package logger
+
+type Logger interface {
+	Logf(format string, args ...interface{}) (n int, err error)
+}
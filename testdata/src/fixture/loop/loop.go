@@ -0,0 +1,12 @@
+// Package loop declares interfaces embedding each other, which doesn't
+// compile.
+package loop
+
+type A interface {
+	B
+	Get() int
+}
+
+type B interface {
+	A
+}
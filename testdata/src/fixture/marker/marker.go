@@ -0,0 +1,4 @@
+// Package marker declares an interface without methods.
+package marker
+
+type Sentinel interface{}
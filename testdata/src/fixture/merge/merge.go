@@ -0,0 +1,13 @@
+// Package merge declares an interface whose methods refer to two packages
+// named config.
+package merge
+
+import (
+	"fixture/a"
+	"fixture/b/config"
+)
+
+type Merger interface {
+	a.Getter
+	Set(o *config.Options) error
+}
@@ -0,0 +1,36 @@
+// Package multi declares several interfaces, of which only Source, Sink
+// and Marker can be mocked in another package, a type constraint and an
+// unexported interface.
+package multi
+
+import "io"
+
+type Source interface {
+	Fetch(key string) ([]byte, error)
+}
+
+type Sink interface {
+	io.Writer
+	Flush() error
+}
+
+type Number interface {
+	~int | ~float64
+}
+
+type closer interface {
+	Close() error
+}
+
+// Sealed cannot be implemented outside multi.
+type Sealed interface {
+	Open() error
+	seal()
+}
+
+// Entries uses the unexported type entry.
+type Entries interface {
+	Next() entry
+}
+
+type entry struct{ key string }
@@ -0,0 +1,7 @@
+package multi
+
+type (
+	Marker interface{}
+
+	Point struct{ X, Y int }
+)
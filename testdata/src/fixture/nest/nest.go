@@ -0,0 +1,13 @@
+// Package nest declares an interface with deeply nested composite types.
+package nest
+
+import "io"
+
+type T struct{}
+
+type Nested interface {
+	A(p *[]map[string]*T) map[*T][]*[2]T
+	B(p []map[T]*[]io.Reader, f func(*T) []*T) chan<- *[]map[string]T
+	C(x ...*[]map[string]*T) (*[]*map[string][]T, error)
+	D(m map[string]map[io.Reader][]func(...*T) error) [][]*io.Reader
+}
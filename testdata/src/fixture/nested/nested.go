@@ -0,0 +1,15 @@
+// Package nested declares an interface whose params nest exported types
+// of its own package in maps, slices and variadics.
+package nested
+
+type Option struct {
+	Name string
+}
+
+type Item int
+
+type Store interface {
+	Put(items map[string][]Item, opts ...Option) error
+	Index() map[Item][]*Option
+	Batch(groups [][]map[string]Item, more ...[]Item) [2][]Item
+}
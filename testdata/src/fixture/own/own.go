@@ -0,0 +1,14 @@
+// Package own declares an interface referring to types of its package,
+// to generate mocks into.
+package own
+
+import "io"
+
+type Request struct{ Body io.Reader }
+
+type Response struct{ Status int }
+
+type Handler interface {
+	Serve(r *Request) (*Response, error)
+	Routes() map[string]Handler
+}
@@ -0,0 +1,12 @@
+// Package results declares an interface with methods of 0 to 3 results,
+// named and unnamed, with and without errors.
+package results
+
+type Results interface {
+	None()
+	Err() error
+	NamedErr() (err error)
+	Two() (int, error)
+	Three() (s string, p []byte, err error)
+	Bools() (bool, bool, bool)
+}
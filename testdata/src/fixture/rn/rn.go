@@ -0,0 +1,12 @@
+// Package rn declares an interface whose params and results are named
+// like the default receiver variable t.
+package rn
+
+import "testing"
+
+type Runner interface {
+	Run(t *testing.T)
+	Get() (t int, err error)
+	Both(t string, arg0 int) (arg1 bool)
+	Anon(string, int) (t error)
+}
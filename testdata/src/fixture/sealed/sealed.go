@@ -0,0 +1,7 @@
+// Package sealed declares an interface with an unexported method.
+package sealed
+
+type Shape interface {
+	Area() float64
+	sealed()
+}
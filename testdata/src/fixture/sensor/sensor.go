@@ -0,0 +1,9 @@
+// Package sensor declares an interface returning a named numeric type of
+// another package.
+package sensor
+
+import "fixture/temp"
+
+type Sensor interface {
+	Read() (temp.Celsius, error)
+}
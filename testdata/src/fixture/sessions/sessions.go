@@ -0,0 +1,17 @@
+// Package sessions declares interfaces embedding instantiated generic
+// interfaces of its own and another package.
+package sessions
+
+import "fixture/cache"
+
+type Session struct{ User string }
+
+type Lister[T any] interface {
+	List(filter func(T) bool) []T
+}
+
+type Store interface {
+	cache.Cache[string, *Session]
+	Lister[Session]
+	Close() error
+}
@@ -0,0 +1,8 @@
+// Package shadow declares an interface with a param named like a package.
+package shadow
+
+import "time"
+
+type Timer interface {
+	After(time time.Duration) time.Time
+}
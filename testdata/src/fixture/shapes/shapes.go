@@ -0,0 +1,24 @@
+// Package shapes declares an interface returning values of all kinds of
+// types.
+package shapes
+
+import (
+	"io"
+	"time"
+)
+
+type Point struct{ X, Y int }
+
+type Level int
+
+type Shapes interface {
+	Chan() chan int
+	Map() map[string][]int
+	Iface() io.Reader
+	Empty() interface{}
+	Struct() Point
+	Anon() struct{ N int }
+	Array() [2]Point
+	Named() Level
+	Time() (time.Time, error)
+}
@@ -0,0 +1,14 @@
+// Package store declares an interface with variadic, error and several
+// results, whose params are named like locals of the generated methods.
+package store
+
+import "io"
+
+type Store interface {
+	Get(ret int) (string, error)
+	Put(key string, v []byte) error
+	Each(r0 string, fs ...func(string) bool) (n int, err error)
+	Open(mr, varargs string, _ret int) (io.ReadCloser, bool, error)
+	Check(reflect bool, gomock ...string) error
+	Reset()
+}
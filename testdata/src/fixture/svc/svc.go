@@ -0,0 +1,24 @@
+// Package svc declares an interface with pointer, slice, struct and
+// standard library results, and a Close method.
+package svc
+
+import (
+	"context"
+	"time"
+)
+
+type Config struct{ Name string }
+
+// NewConfig returns the default Config.
+func NewConfig() *Config { return &Config{Name: "default"} }
+
+type Stats struct{ Hits int }
+
+type Service interface {
+	// Get returns the config of key.
+	Get(ctx context.Context, key string) (*Config, error)
+	List(prefix string, limit int) ([]string, error)
+	Since() time.Time
+	Stats() Stats
+	Close() error
+}
@@ -0,0 +1,3 @@
+// Package tagged declares an interface in a file gated by the integration
+// build tag.
+package tagged
@@ -0,0 +1,7 @@
+//go:build integration
+
+package tagged
+
+type Store interface {
+	Load(key string) ([]byte, error)
+}
@@ -0,0 +1,3 @@
+package temp
+
+type Celsius float64
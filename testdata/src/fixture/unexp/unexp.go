@@ -0,0 +1,19 @@
+// Package unexp declares an interface returning a struct with unexported
+// fields.
+package unexp
+
+type Result struct {
+	Name  string
+	count int
+}
+
+// NewResult returns a Result of name counted n times.
+func NewResult(name string, n int) Result { return Result{name, n} }
+
+// Count returns the count of r.
+func (r Result) Count() int { return r.count }
+
+type Source interface {
+	Fetch(name string) (Result, error)
+	All() []Result
+}
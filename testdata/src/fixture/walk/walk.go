@@ -0,0 +1,10 @@
+// Package walk declares an interface taking and returning callbacks.
+package walk
+
+type Info struct{ Name string }
+
+type Walker interface {
+	Walk(root string, fn func(path string, info Info) error) error
+	Filter() func(info *Info) (skip bool, err error)
+	Events() <-chan Info
+}
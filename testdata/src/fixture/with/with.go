@@ -0,0 +1,8 @@
+// Package with declares an interface whose methods collide with -builder
+// methods.
+package with
+
+type Options interface {
+	Timeout() int
+	WithTimeout(n int) Options
+}
@@ -0,0 +1,18 @@
+// Package zero declares an interface whose results need zero values of
+// composite types.
+package zero
+
+import (
+	"bytes"
+	"io"
+)
+
+type Results interface {
+	Bytes() *[]byte
+	Array() [4]byte
+	Buffer() *bytes.Buffer
+	Map() *map[string]int
+	Count() *int
+	Reader() *io.Reader
+	Writer() io.Writer
+}
@@ -0,0 +1,14 @@
+// Package yaml stands in for gopkg.in/yaml.v3, whose import path has a dot
+// in its last element.
+package yaml
+
+// Marshaler is implemented by types that marshal themselves into YAML.
+type Marshaler interface {
+	MarshalYAML() (interface{}, error)
+}
+
+// IsZeroer is used to check whether an object is zero to determine
+// whether it should be omitted when marshaling with the omitempty flag.
+type IsZeroer interface {
+	IsZero() bool
+}
@@ -0,0 +1,114 @@
+package testgen
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GenerateFromType generates a mock recv in the package pkg implementing
+// the interface type t, from its method set rather than its source.
+// Reflection doesn't know parameter names, so params are named argN. The
+// mock is generated as cfg sets, with its receiver variable named t, its
+// style mock and its pointer results nil unless cfg sets them, and passed
+// to cfg.PostProcess, if set, before it is formatted.
+func GenerateFromType(pkg, recv string, t reflect.Type, cfg Config) ([]byte, error) {
+	if t.Kind() != reflect.Interface {
+		return nil, Error{fmt.Errorf("not an interface: %s", t), ErrNotInterface}
+	}
+	if t.Name() == "" || t.PkgPath() == "" {
+		return nil, fmt.Errorf("not a named interface: %s", t)
+	}
+
+	var fns []Func
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		fn := Func{Name: m.Name}
+		for j := 0; j < m.Type.NumIn(); j++ {
+			param := reflectParam(m.Type.In(j))
+			if m.Type.IsVariadic() && j == m.Type.NumIn()-1 {
+				param.Type = "..." + strings.TrimPrefix(param.Type, "[]")
+				param.Variadic = true
+			}
+			fn.Params = append(fn.Params, param)
+		}
+		for j := 0; j < m.Type.NumOut(); j++ {
+			fn.Res = append(fn.Res, reflectParam(m.Type.Out(j)))
+		}
+		fns = append(fns, fn)
+	}
+
+	if cfg.RecvName == "" {
+		cfg.RecvName = "t"
+	}
+	if cfg.Style == "" {
+		cfg.Style = "mock"
+	}
+	if cfg.PointerZero == "" {
+		cfg.PointerZero = "nil"
+	}
+	// e.g. io.Reader
+	return Generate(TypeTmpl, t.String(), t.PkgPath(), pkg, recv, fns, cfg)
+}
+
+// reflectParam returns the unnamed param of type t.
+func reflectParam(t reflect.Type) Param {
+	p := Param{Type: t.String(), Kinds: make(map[string]string), Imports: make(map[string]string)}
+	if t.Name() != "" && t.PkgPath() != "" {
+		p.Ref = t.PkgPath() + "." + t.Name()
+	}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		if t.Name() != "" {
+			p.Kinds[t.String()] = reflectKind(t)
+			if t.PkgPath() != "" {
+				name := t.String()
+				p.Imports[name[:strings.Index(name, ".")]] = t.PkgPath()
+			}
+			return
+		}
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Chan:
+			walk(t.Elem())
+		case reflect.Map:
+			walk(t.Key())
+			walk(t.Elem())
+		case reflect.Func:
+			for i := 0; i < t.NumIn(); i++ {
+				walk(t.In(i))
+			}
+			for i := 0; i < t.NumOut(); i++ {
+				walk(t.Out(i))
+			}
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				walk(t.Field(i).Type)
+			}
+		}
+	}
+	walk(t)
+	return p
+}
+
+// reflectKind returns the kind of t as Pkg.kind names it.
+func reflectKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Interface:
+		return "interface"
+	case reflect.Struct:
+		return "struct"
+	case reflect.Ptr:
+		return "pointer"
+	case reflect.Slice:
+		return "slice"
+	case reflect.Array:
+		return "array"
+	case reflect.Map:
+		return "map"
+	case reflect.Chan:
+		return "chan"
+	case reflect.Func:
+		return "func"
+	}
+	return "basic"
+}
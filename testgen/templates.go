@@ -0,0 +1,447 @@
+package testgen
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TypeTmpl generates a mock of the mock style, a struct of funcs the
+// methods call.
+var TypeTmpl = `{{$recv := .Recv}}{{$rname := .RecvName}}{{$type := printf "%s%s" .Recv .TypeArgs}}
+{{.Header}}
+package {{ .Package }}
+{{template "imports" .Imports}}
+{{if ne .Part "methods"}}
+{{with .StructComment}}{{comment .}}{{else}}// {{$recv}} ...{{end}}{{nolint}}
+type {{$recv}}{{.TypeParams}} struct {
+	{{if .EmbedIface}}{{.Iface}}
+
+	{{end}}{{if .Spy}}// Real implements the methods whose func is not set, unless it is nil.
+	Real {{.Iface}}
+
+	{{end}}{{if .Log}}{{template "logger"}}
+
+	{{end}}{{if eq .Sync "coarse"}}mu sync.RWMutex // guards the Calls and Returns fields
+
+	{{end}}{{range .Methods}}{{with .Doc}}{{comment .}}
+	{{end}}{{.Name}}Func func({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}})
+	{{if $.Capture}}{{.Name}}Calls []{{$recv}}{{.Name}}Call{{$.TypeArgs}}
+	{{end}}{{if and $.Queue .Res}}{{.Name}}Returns []{{$recv}}{{.Name}}Return{{$.TypeArgs}}
+	{{end}}{{if and (eq $.Sync "fine") (or $.Capture (and $.Queue .Res))}}mu{{.Name}} sync.RWMutex
+	{{end}}{{end}}
+}
+{{template "assert" .}}{{template "zero" .}}
+{{if .Capture}}{{range .Methods}}
+// {{$recv}}{{.Name}}Call holds the arguments of a call to {{$recv}}.{{.Name}}.
+type {{$recv}}{{.Name}}Call{{$.TypeParams}} struct {
+	{{range .Params}}{{field .Name}} {{if .Variadic}}{{sliceType .Type}}{{else}}{{.Type}}{{end}}
+	{{end}}
+}
+{{end}}{{end}}{{if .Dump}}
+// Dump describes the calls recorded by {{$recv}}, the number of calls to
+// each method and the arguments of the last one, for debugging tests.
+func ({{$rname}} *{{$type}}) Dump() string {
+	var _b strings.Builder
+	_b.WriteString("{{$recv}}:")
+	{{range .Methods}}{{with mutex .Name}}{{$rname}}.{{.}}.RLock()
+	{{end}}fmt.Fprintf(&_b, "\n\t{{.Name}}: %d calls", len({{$rname}}.{{.Name}}Calls))
+	{{if .Params}}if _n := len({{$rname}}.{{.Name}}Calls); _n > 0 {
+		fmt.Fprintf(&_b, ", last with %+v", {{$rname}}.{{.Name}}Calls[_n-1])
+	}
+	{{end}}{{with mutex .Name}}{{$rname}}.{{.}}.RUnlock()
+	{{end}}{{end}}return _b.String()
+}
+{{end}}{{if .Queue}}{{range .Methods}}{{if .Res}}
+// {{$recv}}{{.Name}}Return holds the results of a call to {{$recv}}.{{.Name}}.
+type {{$recv}}{{.Name}}Return{{$.TypeParams}} struct {
+	{{range $i, $_ := .Res}}R{{$i}} {{.Type}}
+	{{end}}
+}
+{{end}}{{end}}{{end}}{{if .Log}}{{template "logf" .}}{{end}}{{end}}
+{{if ne .Part "struct"}}{{range .Methods}}
+{{with .Doc}}{{comment .}}{{else}}{{with .Comment}}{{comment .}}{{else}}// {{.Name}} ...{{end}}{{end}}{{nolint}}
+func ({{$rname}} *{{$type}}){{.Name}}({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}}) {
+	{{if $.Log}}{{$rname}}.logf("{{$recv}}.{{.Name}}({{range $i, $_ := .Params}}{{if $i}}, {{end}}%+v{{end}})"{{range .Params}}, {{.Name}}{{end}})
+	{{end}}{{if $.Capture}}{{with mutex .Name}}{{$rname}}.{{.}}.Lock()
+	{{end}}{{$rname}}.{{.Name}}Calls = append({{$rname}}.{{.Name}}Calls, {{$recv}}{{.Name}}Call{{$.TypeArgs}}{ {{range .Params}}{{.Name}}, {{end}} })
+	{{with mutex .Name}}{{$rname}}.{{.}}.Unlock()
+	{{end}}{{end}}if {{$rname}}.{{.Name}}Func != nil {
+		{{if .Res}}return {{end}}{{$rname}}.{{.Name}}Func({{range .Params}}{{.Name}}{{ if variadic .Type }}...{{ end }}, {{end}})
+		{{- if not .Res}}
+		return{{end}}
+	}
+	{{- if $.Spy}}
+	if {{$rname}}.Real != nil {
+		{{if .Res}}return {{end}}{{$rname}}.Real.{{.Name}}({{range .Params}}{{.Name}}{{ if variadic .Type }}...{{ end }}, {{end}})
+		{{- if not .Res}}
+		return{{end}}
+	}
+	{{- end}}
+	{{- if and $.Queue .Res}}{{$mu := mutex .Name}}
+	{{with $mu}}{{$rname}}.{{.}}.Lock()
+	{{end}}if len({{$rname}}.{{.Name}}Returns) > 0 {
+		_r := {{$rname}}.{{.Name}}Returns[0]
+		{{$rname}}.{{.Name}}Returns = {{$rname}}.{{.Name}}Returns[1:]
+		{{with $mu}}{{$rname}}.{{.}}.Unlock()
+		{{end}}return {{range $i, $_ := .Res}}{{if $i}}, {{end}}_r.R{{$i}}{{end}}
+	}
+	{{- with $mu}}
+	{{$rname}}.{{.}}.Unlock(){{end}}
+	{{- end}}
+	{{- if $.EmbedIface}}
+	{{if .Res}}return {{end}}{{$rname}}.{{$.IfaceField}}.{{.Name}}({{range .Params}}{{.Name}}{{ if variadic .Type }}...{{ end }}, {{end}})
+	{{- else if $.Strict}}
+	panic("{{$recv}}.{{.Name}}: not implemented")
+	{{- else}}{{with returns .}}
+	{{.}}{{end}}{{end}}
+}
+{{if $.Asserts}}
+// Assert{{.Name}}CalledWith reports an error to _tb unless the last call to
+// {{.Name}} had the given arguments.
+func ({{$rname}} *{{$type}}) Assert{{.Name}}CalledWith(_tb testing.TB, {{range .Params}}{{.Name}} {{.Type}}, {{end}}) {
+	_tb.Helper()
+	{{with mutex .Name}}{{$rname}}.{{.}}.RLock()
+	defer {{$rname}}.{{.}}.RUnlock()
+	{{end}}if len({{$rname}}.{{.Name}}Calls) == 0 {
+		_tb.Errorf("{{$recv}}.{{.Name}} was not called")
+		return
+	}
+	if _got, _want := {{$rname}}.{{.Name}}Calls[len({{$rname}}.{{.Name}}Calls)-1], ({{$recv}}{{.Name}}Call{{$.TypeArgs}}{ {{range .Params}}{{.Name}}, {{end}} }); !reflect.DeepEqual(_got, _want) {
+		_tb.Errorf("{{$recv}}.{{.Name}} called with %+v, want %+v", _got, _want)
+	}
+}
+{{end}}{{if and $.ExpectClose (eq .Name "Close")}}
+// ExpectClosed reports an error to _tb unless Close was called.
+func ({{$rname}} *{{$type}}) ExpectClosed(_tb testing.TB) {
+	_tb.Helper()
+	{{with mutex .Name}}{{$rname}}.{{.}}.RLock()
+	defer {{$rname}}.{{.}}.RUnlock()
+	{{end}}if len({{$rname}}.CloseCalls) == 0 {
+		_tb.Errorf("{{$recv}}.Close was not called")
+	}
+}
+{{end}}{{if $.Builder}}
+// With{{.Name}} sets {{.Name}}Func to fn and returns {{$rname}}, for chaining.
+func ({{$rname}} *{{$type}}) With{{.Name}}(fn func({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}})) *{{$type}} {
+	{{$rname}}.{{.Name}}Func = fn
+	return {{$rname}}
+}
+{{end}}{{end}}{{end}}
+`
+
+// DelegateTmpl generates a struct delegating to an implementation of the
+// interface.
+var DelegateTmpl = `{{$recv := .Recv}}{{$rname := .RecvName}}{{$type := printf "%s%s" .Recv .TypeArgs}}
+{{.Header}}
+package {{ .Package }}
+{{template "imports" .Imports}}
+{{with .StructComment}}{{comment .}}{{else}}// {{$recv}} ...{{end}}{{nolint}}
+type {{$recv}}{{.TypeParams}} struct {
+	// Impl implements the methods of {{$recv}}, which return zero values
+	// while it is nil.
+	Impl {{.Iface}}
+	{{if .Log}}
+	{{template "logger"}}
+	{{end}}
+}
+{{template "assert" .}}{{template "zero" .}}
+{{if .Log}}{{template "logf" .}}{{end}}
+{{range .Methods}}
+{{with .Doc}}{{comment .}}{{else}}{{with .Comment}}{{comment .}}{{else}}// {{.Name}} ...{{end}}{{end}}{{nolint}}
+func ({{$rname}} *{{$type}}){{.Name}}({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}}) {
+	{{if $.Log}}{{$rname}}.logf("{{$recv}}.{{.Name}}({{range $i, $_ := .Params}}{{if $i}}, {{end}}%+v{{end}})"{{range .Params}}, {{.Name}}{{end}})
+	{{end}}if {{$rname}}.Impl != nil {
+		{{if .Res}}return {{end}}{{$rname}}.Impl.{{.Name}}({{range .Params}}{{.Name}}{{ if variadic .Type }}...{{ end }}, {{end}})
+		{{- if not .Res}}
+		return{{end}}
+	}
+	{{- if $.Strict}}
+	panic("{{$recv}}.{{.Name}}: not implemented")
+	{{- else}}{{with returns .}}
+	{{.}}{{end}}{{end}}
+}
+{{end}}
+`
+
+// TestifyTmpl generates a github.com/stretchr/testify/mock mock.
+var TestifyTmpl = `{{$recv := .Recv}}{{$rname := .RecvName}}{{$type := printf "%s%s" .Recv .TypeArgs}}
+{{.Header}}
+package {{ .Package }}
+
+import (
+	"github.com/stretchr/testify/mock"
+{{range .Imports}}	{{.Name}} "{{.Path}}"
+{{end}})
+
+{{with .StructComment}}{{comment .}}{{else}}// {{$recv}} ...{{end}}{{nolint}}
+type {{$recv}}{{.TypeParams}} struct {
+	mock.Mock
+}
+{{template "assert" .}}
+{{range .Methods}}{{$m := .}}
+{{with .Doc}}{{comment .}}{{else}}{{with .Comment}}{{comment .}}{{else}}// {{.Name}} ...{{end}}{{end}}{{nolint}}
+func ({{$rname}} *{{$type}}){{.Name}}({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}}) {
+	{{with variadicParam .Params}}_va := make([]interface{}, len({{.Name}}))
+	for _i := range {{.Name}} {
+		_va[_i] = {{.Name}}[_i]
+	}
+	_ca := []interface{}{ {{range fixedParams $m.Params}}{{.Name}}, {{end}} }
+	_ca = append(_ca, _va...)
+	{{if $m.Res}}_ret := {{end}}{{$rname}}.Called(_ca...)
+	{{- else}}{{if .Res}}_ret := {{end}}{{$rname}}.Called({{range .Params}}{{.Name}}, {{end}})
+	{{- end}}
+	{{- range $i, $e := .Res}}
+	{{if eq .Type "error"}}_r{{$i}} := _ret.Error({{$i}}){{else}}var _r{{$i}} {{.Type}}
+	if _v := _ret.Get({{$i}}); _v != nil {
+		_r{{$i}} = _v.({{.Type}})
+	}{{end}}
+	{{- end}}
+	{{- if .Res}}
+	return {{$resLen := len .Res}}{{range $i, $e := .Res}}_r{{$i}}{{if ne (plus1 $i) $resLen}}, {{end}}{{end}}{{end}}
+}
+{{end}}
+`
+
+// GomockTmpl generates a github.com/golang/mock/gomock mock.
+var GomockTmpl = `{{$recv := .Recv}}{{$rname := .RecvName}}{{$type := printf "%s%s" .Recv .TypeArgs}}
+{{.Header}}
+package {{ .Package }}
+
+import (
+	"reflect"
+
+	"github.com/golang/mock/gomock"
+{{range .Imports}}	{{.Name}} "{{.Path}}"
+{{end}})
+
+{{with .StructComment}}{{comment .}}{{else}}// {{$recv}} is a mock of {{.Iface}}.{{end}}{{nolint}}
+type {{$recv}}{{.TypeParams}} struct {
+	ctrl     *gomock.Controller
+	recorder *{{$recv}}MockRecorder
+}
+{{template "assert" .}}
+
+// {{$recv}}MockRecorder is the mock recorder for {{$recv}}.
+type {{$recv}}MockRecorder struct {
+	mock *{{$recv}}
+}
+
+// New{{$recv}} creates a new mock instance.
+func New{{$recv}}(ctrl *gomock.Controller) *{{$recv}} {
+	mock := &{{$recv}}{ctrl: ctrl}
+	mock.recorder = &{{$recv}}MockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func ({{$rname}} *{{$type}}) EXPECT() *{{$recv}}MockRecorder {
+	return {{$rname}}.recorder
+}
+{{range .Methods}}{{$m := .}}
+{{with .Doc}}{{comment .}}{{else}}{{with .Comment}}{{comment .}}{{else}}// {{.Name}} mocks base method.{{end}}{{end}}{{nolint}}
+func ({{$rname}} *{{$type}}){{.Name}}({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}}) {
+	{{$rname}}.ctrl.T.Helper()
+	{{- with variadicParam .Params}}
+	_varargs := []interface{}{ {{range fixedParams $m.Params}}{{.Name}}, {{end}} }
+	for _, _a := range {{.Name}} {
+		_varargs = append(_varargs, _a)
+	}
+	{{if $m.Res}}_ret := {{end}}{{$rname}}.ctrl.Call({{$rname}}, "{{$m.Name}}", _varargs...)
+	{{- else}}
+	{{if .Res}}_ret := {{end}}{{$rname}}.ctrl.Call({{$rname}}, "{{.Name}}", {{range .Params}}{{.Name}}, {{end}})
+	{{- end}}
+	{{- range $i, $e := .Res}}
+	_ret{{$i}}, _ := _ret[{{$i}}].({{.Type}})
+	{{- end}}
+	{{- if .Res}}
+	return {{$resLen := len .Res}}{{range $i, $e := .Res}}_ret{{$i}}{{if ne (plus1 $i) $resLen}}, {{end}}{{end}}{{end}}
+}
+
+// {{.Name}} indicates an expected call of {{.Name}}.
+func (_mr *{{$recv}}MockRecorder) {{.Name}}({{range fixedParams .Params}}{{.Name}} interface{}, {{end}}{{with variadicParam .Params}}{{.Name}} ...interface{}{{end}}) *gomock.Call {
+	_mr.mock.ctrl.T.Helper()
+	{{- with variadicParam .Params}}
+	_varargs := append([]interface{}{ {{range fixedParams $m.Params}}{{.Name}}, {{end}} }, {{.Name}}...)
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "{{$m.Name}}", reflect.TypeOf((*{{$recv}})(nil).{{$m.Name}}), _varargs...)
+	{{- else}}
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "{{.Name}}", reflect.TypeOf((*{{$recv}})(nil).{{.Name}}), {{range .Params}}{{.Name}}, {{end}})
+	{{- end}}
+}
+{{end}}
+`
+
+// MissingTmpl generates only the methods recv lacks, without a struct.
+var MissingTmpl = `{{$recv := .Recv}}{{$rname := .RecvName}}{{$type := printf "%s%s" .Recv .TypeArgs}}
+{{.Header}}
+package {{ .Package }}
+{{template "imports" .Imports}}
+{{range .Methods}}
+{{with .Doc}}{{comment .}}{{else}}{{with .Comment}}{{comment .}}{{else}}// {{.Name}} ...{{end}}{{end}}{{nolint}}
+func ({{$rname}} *{{$type}}){{.Name}}({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}}) {
+	{{- if $.Strict}}
+	panic("{{$recv}}.{{.Name}}: not implemented")
+	{{- else}}{{with returns .}}
+	{{.}}{{end}}{{end}}
+}
+{{end}}
+`
+
+// StubTmpl generates methods panicking with "not implemented", like impl,
+// for recv to implement, without a struct.
+var StubTmpl = `{{$rname := .RecvName}}{{$type := printf "%s%s" .Recv .TypeArgs}}
+{{.Header}}
+package {{ .Package }}
+{{template "imports" .Imports}}
+{{range .Methods}}
+{{with .Doc}}{{comment .}}{{else}}{{with .Comment}}{{comment .}}{{else}}// {{.Name}} ...{{end}}{{end}}
+func ({{$rname}} *{{$type}}){{.Name}}({{range .Params}}{{.Name}} {{.Type}}, {{end}}) ({{range .Res}}{{.Name}} {{.Type}}, {{end}}) {
+	panic("not implemented") // TODO: Implement
+}
+{{end}}
+`
+
+// logTmpl declares the Logger field and logf method of mocks logging
+// their calls.
+var logTmpl = `{{define "logger"}}// Logger logs the calls, or the standard logger if it is nil.
+	Logger interface{ Printf(format string, v ...interface{}) }{{end}}
+{{define "logf"}}
+// logf logs a call to {{.Recv}} with Logger.
+func ({{.RecvName}} *{{.Recv}}{{.TypeArgs}}) logf(format string, v ...interface{}) {
+	if {{.RecvName}}.Logger != nil {
+		{{.RecvName}}.Logger.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
+{{end}}`
+
+// zeroTmpl declares the generic function returning zero values, named
+// ZeroFunc, if results use it.
+var zeroTmpl = `{{define "zero"}}{{with .ZeroFunc}}
+// {{.}} returns the zero value of T.
+func {{.}}[T any]() T {
+	var z T
+	return z
+}
+{{end}}{{end}}`
+
+// importsTmpl declares a list of Imports.
+var importsTmpl = `{{define "imports"}}{{if .}}
+import (
+{{range .}}	{{.Name}} "{{.Path}}"
+{{end}})
+{{end}}{{end}}`
+
+// assertTmpl asserts that the generated type implements the interface,
+// unless the interface is generic or may be a concrete type, or only some
+// of its methods are implemented.
+var assertTmpl = `{{define "assert"}}{{if not (or .Generic .Concrete .Partial .Internal)}}
+var _ {{.Iface}} = (*{{.Recv}})(nil)
+{{end}}{{end}}`
+
+var funcMapFunc = func(self string, cfg Config) template.FuncMap {
+	// constructor returns the default value for the type of res. Methods
+	// returning the interface itself, referred to by self, return the
+	// receiver, named by recv.
+	constructor := func(res Param, recv string) string {
+		if res.Type == "error" {
+			return "nil"
+		}
+		if self != "" && res.Ref == self {
+			return recv
+		}
+		if v, ok := cfg.Defaults[res.Type]; ok {
+			return v
+		}
+		if key, qual := namedKey(res, cfg.PkgPath); cfg.constructors[key] != "" {
+			if qual != "" {
+				return qual + "." + cfg.constructors[key] + "()"
+			}
+			return cfg.constructors[key] + "()"
+		}
+		if v, ok := stdDefaults[res.Type]; ok {
+			if qual := strings.SplitN(res.Type, ".", 2)[0]; res.Imports[qual] == qual {
+				return v
+			}
+		}
+		return cfg.zeroValue(res.Type, res.Kinds)
+	}
+
+	return template.FuncMap{
+		"plus1": func(x int) int {
+			return x + 1
+		},
+		"constructor": constructor,
+		// mutex returns the name of the field guarding the recorded calls
+		// and queued results of the method name, or "" without Sync.
+		"mutex": func(name string) string {
+			switch cfg.Sync {
+			case "coarse":
+				return "mu"
+			case "fine":
+				return "mu" + name
+			}
+			return ""
+		},
+		// nolint returns a //nolint:all directive on a line of its own, to
+		// follow the doc comment of a declaration, if LintSuppress is set.
+		"nolint": func() string {
+			if !cfg.LintSuppress {
+				return ""
+			}
+			return "\n//nolint:all"
+		},
+		// returns returns the statement returning the default results
+		// of m, or "" if m has no results.
+		"returns": func(m Method) string {
+			if len(m.Res) == 0 {
+				return ""
+			}
+			if vals, ok := cfg.Returns[m.Name]; ok {
+				return "return " + strings.Join(vals, ", ")
+			}
+			vals := make([]string, len(m.Res))
+			for i, res := range m.Res {
+				vals[i] = constructor(res, m.Recv)
+			}
+			return "return " + strings.Join(vals, ", ")
+		},
+		// comment turns text into a // comment.
+		"comment": func(text string) string {
+			lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSpace("// " + line)
+			}
+			return strings.Join(lines, "\n")
+		},
+		"variadic": func(typ string) bool {
+			return strings.HasPrefix(typ, "...")
+		},
+		// variadicParam returns the variadic param of params, if any.
+		"variadicParam": func(params []Param) *Param {
+			if len(params) == 0 || !params[len(params)-1].Variadic {
+				return nil
+			}
+			return &params[len(params)-1]
+		},
+		// field returns the exported struct field name for param name.
+		"field": func(name string) string {
+			r, n := utf8.DecodeRuneInString(name)
+			return string(unicode.ToUpper(r)) + name[n:]
+		},
+		// sliceType returns the type of the slice a param of type typ is,
+		// e.g. []int for ...int.
+		"sliceType": func(typ string) string {
+			return "[]" + strings.TrimPrefix(typ, "...")
+		},
+		// fixedParams returns params without the variadic param.
+		"fixedParams": func(params []Param) []Param {
+			if len(params) == 0 || !params[len(params)-1].Variadic {
+				return params
+			}
+			return params[:len(params)-1]
+		},
+	}
+}
@@ -0,0 +1,837 @@
+// Package testgen generates implementations of interfaces for tests from
+// their method sets, as the testgen command does from their source.
+package testgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	pathpkg "path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/imports"
+)
+
+// DefaultHeader is the comment placed before the package clause of
+// generated files, marking them as generated.
+const DefaultHeader = "// Code generated by testgen; DO NOT EDIT."
+
+// Error classes of the errors of Generate, which the testgen command maps
+// to exit codes.
+var (
+	ErrUsage        = errors.New("usage error")
+	ErrNotInterface = errors.New("not an interface")
+	ErrGenerate     = errors.New("couldn't generate code")
+)
+
+// Error is an error of the class Class, such as ErrUsage, which
+// errors.Is reports, with the message of Err.
+type Error struct {
+	Err   error
+	Class error
+}
+
+func (e Error) Error() string        { return e.Err.Error() }
+func (e Error) Is(target error) bool { return target == e.Class }
+func (e Error) Unwrap() error        { return e.Err }
+
+// Method represents a method signature.
+type Method struct {
+	Recv string
+	// Comment replaces the placeholder comment of methods without Doc.
+	Comment string
+	Func
+}
+
+// Func represents a function signature.
+type Func struct {
+	Name   string  `json:"name"`
+	Params []Param `json:"params,omitempty"`
+	Res    []Param `json:"results,omitempty"`
+	// Doc is the documentation of the interface method, if any.
+	Doc string `json:"doc,omitempty"`
+}
+
+// Param represents a parameter in a function or method signature.
+type Param struct {
+	Name     string `json:"name,omitempty"`
+	Type     string `json:"type"`
+	Variadic bool   `json:"variadic,omitempty"`
+	// Kinds holds the kind of each named type in Type, such as
+	// "interface", "struct" or "basic", or "" if it is unknown.
+	Kinds map[string]string `json:"-"`
+	// Imports holds the import path of each package referenced in Type,
+	// keyed by package name.
+	Imports map[string]string `json:"-"`
+	// Ref is the import path and name of Type if it is a named type,
+	// e.g. "io.Reader" or "gopkg.in/yaml.v3.Node", however it is spelled.
+	Ref string `json:"-"`
+}
+
+// String returns the signature of f as it would be declared in an
+// interface, e.g. "Read(p []byte) (n int, err error)".
+func (f Func) String() string {
+	list := func(params []Param) string {
+		var s []string
+		for _, p := range params {
+			s = append(s, strings.TrimSpace(p.Name+" "+p.Type))
+		}
+		return strings.Join(s, ", ")
+	}
+	sig := f.Name + "(" + list(f.Params) + ")"
+	switch {
+	case len(f.Res) == 1 && f.Res[0].Name == "":
+		sig += " " + f.Res[0].Type
+	case len(f.Res) > 0:
+		sig += " (" + list(f.Res) + ")"
+	}
+	return sig
+}
+
+// SameSignature reports whether f and g have the same parameter and
+// result types, ignoring names.
+func (f Func) SameSignature(g Func) bool {
+	same := func(a, b []Param) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i].Type != b[i].Type {
+				return false
+			}
+		}
+		return true
+	}
+	return same(f.Params, g.Params) && same(f.Res, g.Res)
+}
+
+// importable reports whether the package with the import path may be
+// imported by the package importer: internal packages only by the tree
+// rooted at the parent of their internal directory.
+func importable(path, importer string) bool {
+	path = Unvendor(path)
+	var parent string
+	if strings.HasSuffix(path, "/internal") {
+		parent = strings.TrimSuffix(path, "/internal")
+	} else if i := strings.LastIndex(path, "/internal/"); i >= 0 {
+		parent = path[:i]
+	} else if path == "internal" || strings.HasPrefix(path, "internal/") {
+		// internal to the standard library
+		return false
+	} else {
+		return true
+	}
+	return importer == parent || strings.HasPrefix(importer, parent+"/")
+}
+
+// Unvendor returns the import path of a vendored package as it is
+// imported, e.g. "github.com/x/y" for "a/b/vendor/github.com/x/y".
+func Unvendor(path string) string {
+	if i := strings.LastIndex(path, "/vendor/"); i >= 0 {
+		return path[i+len("/vendor/"):]
+	}
+	return strings.TrimPrefix(path, "vendor/")
+}
+
+// Import is an import of the generated file.
+type Import struct {
+	Name string // empty unless the package is renamed
+	Path string
+}
+
+// nameParams returns a copy of params in which anonymous and blank
+// params are named argN, so that methods can forward them. So are params
+// that would clash with the generated code: those named like one of
+// reserved, such as the receiver variable, and those starting with an
+// underscore, like the locals of the generated methods.
+func nameParams(params []Param, reserved ...string) []Param {
+	used := make(map[string]bool)
+	for _, name := range reserved {
+		used[name] = true
+	}
+	for _, p := range params {
+		used[p.Name] = true
+	}
+	res := make([]Param, len(params))
+	for i, p := range params {
+		if p.Name == "" || p.Name == "_" || clashes(p.Name, reserved) {
+			for n := i; ; n++ {
+				p.Name = "arg" + strconv.Itoa(n)
+				if !used[p.Name] {
+					break
+				}
+			}
+			used[p.Name] = true
+		}
+		res[i] = p
+	}
+	return res
+}
+
+// nameResults returns a copy of the results res in which those that
+// would clash with the generated code, as for nameParams, or with reserved
+// names such as the params, are named resN instead. Anonymous and blank
+// results are kept.
+func nameResults(res []Param, reserved ...string) []Param {
+	used := make(map[string]bool)
+	for _, name := range reserved {
+		used[name] = true
+	}
+	for _, r := range res {
+		used[r.Name] = true
+	}
+	out := make([]Param, len(res))
+	for i, r := range res {
+		if r.Name != "" && r.Name != "_" && clashes(r.Name, reserved) {
+			for n := i; ; n++ {
+				r.Name = "res" + strconv.Itoa(n)
+				if !used[r.Name] {
+					break
+				}
+			}
+			used[r.Name] = true
+		}
+		out[i] = r
+	}
+	return out
+}
+
+// clashes reports whether a param name is one of reserved or starts with
+// an underscore, like the locals of the generated methods.
+func clashes(name string, reserved []string) bool {
+	if strings.HasPrefix(name, "_") {
+		return true
+	}
+	for _, r := range reserved {
+		if name == r {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveImports returns the imports needed by fns. Packages sharing a name
+// are given distinct names, and the params referring to them are renamed
+// accordingly in the returned copy of fns. Types of the package with the
+// import path local, which the code is generated into, are not qualified.
+// The packages in pinned keep their names, followed by the package of the
+// implemented interface, iface, whose resolved qualifier is returned
+// unless iface has no path. Other packages are renamed rather than named
+// like the identifiers in reserved, such as the receiver type.
+func ResolveImports(fns []Func, pinned map[string]string, iface Import, local string, reserved ...string) ([]Func, []Import, string) {
+	paths := make(map[string]string) // by name
+	names := make(map[string]string) // by path
+	var imports []Import
+	add := func(name, path string) {
+		paths[name], names[path] = path, name
+		imp := Import{Path: path}
+		if name != pathpkg.Base(path) {
+			imp.Name = name
+		}
+		imports = append(imports, imp)
+	}
+	// Add the pinned packages in order, so that the last of several names
+	// pinned to the same path is used every time.
+	var pins []string
+	for name := range pinned {
+		pins = append(pins, name)
+	}
+	sort.Strings(pins)
+	for _, name := range pins {
+		if pinned[name] != local {
+			add(name, pinned[name])
+		}
+	}
+	for _, name := range reserved {
+		if paths[name] == "" {
+			paths[name] = "-" // not a package
+		}
+	}
+	// name returns the name path is imported under, or "" for the
+	// local package, which is not imported.
+	name := func(name, path string) string {
+		if path == local {
+			return ""
+		}
+		if n, ok := names[path]; ok {
+			return n
+		}
+		n := name
+		for i := 2; paths[n] != ""; i++ {
+			n = name + strconv.Itoa(i)
+		}
+		add(n, path)
+		return n
+	}
+	var qual string
+	if iface.Path != "" {
+		qual = name(iface.Name, iface.Path)
+	}
+	rename := func(params []Param) []Param {
+		res := make([]Param, len(params))
+		for i, param := range params {
+			var qual []string
+			for n := range param.Imports {
+				qual = append(qual, n)
+			}
+			sort.Strings(qual)
+			renames := make(map[string]string)
+			for _, n := range qual {
+				if nn := name(n, param.Imports[n]); nn != n {
+					renames[n] = nn
+				}
+			}
+			res[i] = param.rename(renames)
+		}
+		return res
+	}
+
+	res := make([]Func, len(fns))
+	for i, fn := range fns {
+		fn.Params, fn.Res = rename(fn.Params), rename(fn.Res)
+		res[i] = fn
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		if imports[i].Path != imports[j].Path {
+			return imports[i].Path < imports[j].Path
+		}
+		return imports[i].Name < imports[j].Name
+	})
+	return res, imports, qual
+}
+
+// ReservedNames returns the identifiers of the generated code that would
+// shadow packages of the same name: the receiver types recvs, the receiver
+// variable and the parameters of fns.
+func ReservedNames(cfg Config, fns []Func, recvs ...string) []string {
+	names := append([]string{cfg.RecvName}, recvs...)
+	for _, fn := range fns {
+		for _, param := range append(fn.Params, fn.Res...) {
+			if param.Name != "" && param.Name != "_" {
+				names = append(names, param.Name)
+			}
+		}
+	}
+	return names
+}
+
+// addImports returns imps with the packages of paths added under their
+// own names, unless already imported.
+func addImports(imps []Import, paths ...string) []Import {
+	for _, path := range paths {
+		imported := false
+		for _, imp := range imps {
+			imported = imported || imp.Path == path
+		}
+		if !imported {
+			imps = append(imps, Import{Path: path})
+		}
+	}
+	return imps
+}
+
+// rename returns a copy of p with the packages in Type renamed by renames.
+// Packages renamed to "" are dropped, leaving their types unqualified.
+func (p Param) rename(renames map[string]string) Param {
+	if len(renames) == 0 {
+		return p
+	}
+	typ := strings.TrimPrefix(p.Type, "...")
+	e, err := parser.ParseExpr(typ)
+	if err != nil {
+		return p
+	}
+	e = replaceSelectors(e, func(sel *ast.SelectorExpr) ast.Expr {
+		if x, ok := sel.X.(*ast.Ident); ok {
+			if name, ok := renames[x.Name]; ok && name == "" {
+				return sel.Sel
+			} else if ok {
+				x.Name = name
+			}
+		}
+		return sel
+	})
+	p.Type = p.Type[:len(p.Type)-len(typ)] + types.ExprString(e)
+
+	kinds := make(map[string]string)
+	for name, kind := range p.Kinds {
+		if dot := strings.Index(name, "."); dot > 0 {
+			if nn, ok := renames[name[:dot]]; ok && nn == "" {
+				name = name[dot+1:]
+			} else if ok {
+				name = nn + name[dot:]
+			}
+		}
+		kinds[name] = kind
+	}
+	imports := make(map[string]string)
+	for name, path := range p.Imports {
+		if nn, ok := renames[name]; ok && nn == "" {
+			continue
+		} else if ok {
+			name = nn
+		}
+		imports[name] = path
+	}
+	p.Kinds, p.Imports = kinds, imports
+	return p
+}
+
+// replaceSelectors returns the type e with its qualified identifiers,
+// such as io.Reader, replaced by replace.
+func replaceSelectors(e ast.Expr, replace func(*ast.SelectorExpr) ast.Expr) ast.Expr {
+	fields := func(list *ast.FieldList) {
+		if list == nil {
+			return
+		}
+		for _, f := range list.List {
+			f.Type = replaceSelectors(f.Type, replace)
+		}
+	}
+	switch t := e.(type) {
+	case *ast.SelectorExpr:
+		return replace(t)
+	case *ast.StarExpr:
+		t.X = replaceSelectors(t.X, replace)
+	case *ast.ParenExpr:
+		t.X = replaceSelectors(t.X, replace)
+	case *ast.Ellipsis:
+		t.Elt = replaceSelectors(t.Elt, replace)
+	case *ast.ArrayType:
+		if t.Len != nil {
+			t.Len = replaceSelectors(t.Len, replace)
+		}
+		t.Elt = replaceSelectors(t.Elt, replace)
+	case *ast.MapType:
+		t.Key = replaceSelectors(t.Key, replace)
+		t.Value = replaceSelectors(t.Value, replace)
+	case *ast.ChanType:
+		t.Value = replaceSelectors(t.Value, replace)
+	case *ast.IndexExpr:
+		t.X = replaceSelectors(t.X, replace)
+		t.Index = replaceSelectors(t.Index, replace)
+	case *ast.IndexListExpr:
+		t.X = replaceSelectors(t.X, replace)
+		for i := range t.Indices {
+			t.Indices[i] = replaceSelectors(t.Indices[i], replace)
+		}
+	case *ast.FuncType:
+		fields(t.Params)
+		fields(t.Results)
+	case *ast.StructType:
+		fields(t.Fields)
+	case *ast.InterfaceType:
+		fields(t.Methods)
+	}
+	return e
+}
+
+// Config controls the generated code.
+type Config struct {
+	// RecvName is the receiver variable name of generated methods.
+	RecvName string
+	// Header is the comment placed before the package clause.
+	Header string
+	// Strict makes methods panic rather than return zero values
+	// when their func is not set.
+	Strict bool
+	// Style selects the kind of generated code: "mock" for a struct
+	// of funcs, "testify" for a testify mock or "gomock" for a gomock mock.
+	Style string
+	// Imports pins package names to import paths.
+	Imports map[string]string
+	// PointerZero controls what pointer results default to:
+	// "nil", or "alloc" for a newly allocated value.
+	PointerZero string
+	// EmbedIface embeds the interface in the generated struct, so that
+	// methods whose func is not set delegate to it. Such methods panic
+	// with a nil dereference if the embedded interface is nil.
+	EmbedIface bool
+	// Defaults maps result types to the expressions they default to,
+	// taking precedence over zero values.
+	Defaults map[string]string
+	// SmartDefaults makes results of a named type T, or *T, default to a
+	// call of NewT or Default of its package, whichever returns that type,
+	// as Declares reports.
+	SmartDefaults bool
+	// Declares reports whether the package with the import path declares
+	// a func fn without params returning only its type name, or a pointer
+	// to it if ptr is set. SmartDefaults requires it.
+	Declares func(path, fn, name string, ptr bool) bool
+	// Sync guards the recorded calls and queued results with a mutex per
+	// mock if it is "coarse", or per method if it is "fine".
+	Sync string
+	// Log makes methods log their arguments with the Logger field.
+	Log bool
+	// Returns maps method names to the expressions of their default
+	// results, taking precedence over Defaults.
+	Returns map[string][]string
+	// constructors maps named types, keyed by namedKey, to the names of
+	// their constructors, for SmartDefaults.
+	constructors map[string]string
+	// ZeroHelper makes results without a literal zero value, such as
+	// structs or named types of unknown kinds, call a generic function
+	// declared with the mock, instead of T{} or *new(T).
+	ZeroHelper bool
+	// zeroFunc is the name of that function.
+	zeroFunc string
+	// Part restricts a mock to its "struct" or its "methods", for
+	// mocks split across files. It is empty for the whole mock.
+	Part string
+	// Capture records the arguments of each call in a slice per method.
+	Capture bool
+	// Asserts adds methods asserting the arguments of the last call to
+	// each method. It requires Capture.
+	Asserts bool
+	// Comment and StructComment are templates of the comments of methods
+	// without docs and of the struct, see RenderComment. Empty templates
+	// leave the placeholders of the style.
+	Comment       string
+	StructComment string
+	// Generic reports whether the interface has type parameters.
+	Generic bool
+	// TypeParams are the type parameters of the interface, which the
+	// generated types declare too.
+	TypeParams []Param
+	// Concrete reports whether the interface may be a concrete type,
+	// which the generated type cannot be assigned to.
+	Concrete bool
+	// PkgPath is the import path of the package the code is generated
+	// into, whose types are not qualified. It may be empty.
+	PkgPath string
+	// ExpectClose adds an ExpectClosed method checking that the Close
+	// method was called. It requires Capture and a Close method.
+	ExpectClose bool
+	// Spy adds a Real field implementing the interface, which methods
+	// whose func is not set call. It requires Capture.
+	Spy bool
+	// Queue adds a FooReturns slice of results for each method Foo, which
+	// successive calls return in order.
+	Queue bool
+	// Builder adds a WithFoo method setting FooFunc for each method Foo.
+	Builder bool
+	// Partial reports whether only some of the methods of the interface
+	// are implemented, so that the generated type doesn't implement it.
+	Partial bool
+	// PostProcess, if set, is called with the generated file before
+	// goimports formats it, and may modify it, e.g. add declarations.
+	PostProcess func(*ast.File) error
+	// Raw skips formatting the output with goimports, to debug templates.
+	Raw bool
+	// LintSuppress adds //nolint:all directives to the generated type
+	// and methods.
+	LintSuppress bool
+	// NoFormat formats the output with gofmt rather than goimports, so
+	// it keeps the imports the templates declare, less the unused ones.
+	NoFormat bool
+	// Warnf, if set, reports problems that don't prevent generating code,
+	// such as leaving out the assertion of an internal interface.
+	Warnf func(format string, args ...interface{})
+	// Filename is the file the output is written to, if any, which
+	// goimports uses to resolve imports from its directory.
+	Filename string
+}
+
+// RenderComment executes the comment template text for the method name,
+// or the struct if name is empty, of a receiver type implementing iface.
+// The result has no comment markers.
+func RenderComment(text, name, iface, recvType string) (string, error) {
+	tmpl, err := template.New("comment").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid comment: %v", err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Name  string
+		Iface string
+		Recv  string
+	}{
+		Name:  name,
+		Iface: iface,
+		Recv:  recvType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid comment: %v", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Generate generates recvType in the package pkg, implementing the methods
+// fns of the interface ifaceName, e.g. io.Reader, with the import path
+// ifacePath, with the template tmpl, one of TypeTmpl, DelegateTmpl,
+// TestifyTmpl, GomockTmpl, MissingTmpl and StubTmpl, and formats it.
+func Generate(tmpl, ifaceName, ifacePath, pkg, recvType string, fns []Func, cfg Config) ([]byte, error) {
+	// A named ifaceName is qualified by its package name, which may be
+	// renamed. Interface literals have no package.
+	var iface Import
+	var self string // import path and name of the interface
+	dot := strings.Index(ifaceName, ".")
+	if ifacePath != "" {
+		iface = Import{Name: ifaceName[:dot], Path: ifacePath}
+		self = ifacePath + ifaceName[dot:]
+	}
+	// A mock outside the tree of an internal package can't import it, so
+	// it can't be asserted to implement the interface, nor use its types.
+	internal := ifacePath != "" && cfg.PkgPath != "" && !importable(ifacePath, cfg.PkgPath)
+	if internal && (cfg.EmbedIface || cfg.Spy || tmpl == DelegateTmpl) {
+		return nil, fmt.Errorf("%s is internal to another tree than %s, which cannot refer to it with Config.EmbedIface, Config.Spy or DelegateTmpl", ifacePath, cfg.PkgPath)
+	}
+	if cfg.PkgPath != "" {
+		for _, fn := range fns {
+			for _, param := range append(append([]Param(nil), fn.Params...), fn.Res...) {
+				for _, path := range param.Imports {
+					if !importable(path, cfg.PkgPath) {
+						return nil, fmt.Errorf("method %s of %s uses a type of the internal package %s, which %s cannot import", fn.Name, ifaceName, path, cfg.PkgPath)
+					}
+				}
+			}
+		}
+	}
+	if internal {
+		if cfg.Warnf != nil {
+			cfg.Warnf("%s cannot import the internal package %s, so the var _ assertion is left out", cfg.PkgPath, ifacePath)
+		}
+	}
+	// The constraints of the type parameters are resolved with the
+	// methods, as the params of an extra func.
+	all := append(append([]Func(nil), fns...), Func{Params: cfg.TypeParams})
+	for _, fn := range fns {
+		if vals, ok := cfg.Returns[fn.Name]; ok && len(vals) != len(fn.Res) {
+			return nil, Error{fmt.Errorf("Config.Returns[%q]: %s returns %d results, got %d", fn.Name, fn.Name, len(fn.Res), len(vals)), ErrUsage}
+		}
+	}
+	if cfg.SmartDefaults {
+		cfg.constructors = constructors(fns, cfg.Declares)
+	}
+	all, imps, qual := ResolveImports(all, cfg.Imports, iface, cfg.PkgPath, ReservedNames(cfg, fns, recvType)...)
+	fns, tparams := all[:len(fns)], all[len(fns)].Params
+	if ifacePath != "" && qual == "" {
+		ifaceName = ifaceName[dot+1:]
+	} else if ifacePath != "" {
+		ifaceName = qual + ifaceName[dot:]
+	}
+	ifaceField := ifaceName[strings.Index(ifaceName, ".")+1:]
+	// e.g. [K comparable, V any] and [K, V]
+	var typeParams, typeArgs string
+	if len(tparams) > 0 {
+		var decl, names []string
+		for i, tp := range tparams {
+			if i+1 < len(tparams) && tparams[i+1].Type == tp.Type {
+				decl = append(decl, tp.Name) // grouped with the next
+			} else {
+				decl = append(decl, tp.Name+" "+tp.Type)
+			}
+			names = append(names, tp.Name)
+		}
+		typeParams = "[" + strings.Join(decl, ", ") + "]"
+		typeArgs = "[" + strings.Join(names, ", ") + "]"
+		ifaceName += typeArgs
+	}
+	if cfg.Asserts {
+		imps = addImports(imps, "reflect", "testing")
+	}
+	if cfg.ExpectClose {
+		imps = addImports(imps, "testing")
+	}
+	// A Dump method of the interface takes precedence.
+	dump := cfg.Capture && cfg.Part != "methods"
+	for _, fn := range fns {
+		dump = dump && fn.Name != "Dump"
+	}
+	if dump {
+		imps = addImports(imps, "fmt", "strings")
+	}
+	if cfg.Sync != "" {
+		imps = addImports(imps, "sync")
+	}
+	if cfg.Log {
+		for _, fn := range fns {
+			if fn.Name == "Logger" || fn.Name == "logf" {
+				return nil, Error{fmt.Errorf("Config.Log: %s has a method %s", ifaceName, fn.Name), ErrGenerate}
+			}
+		}
+		imps = addImports(imps, "log")
+	}
+
+	// The zero value helper is declared only if a method returns it.
+	var zeroFunc string
+	if cfg.ZeroHelper && !cfg.Strict && !cfg.EmbedIface {
+		cfg.zeroFunc = "zero" + recvType
+		constructor := funcMapFunc(self, cfg)["constructor"].(func(Param, string) string)
+		for _, fn := range fns {
+			if _, ok := cfg.Returns[fn.Name]; ok {
+				continue
+			}
+			for _, res := range fn.Res {
+				if strings.HasPrefix(constructor(res, cfg.RecvName), cfg.zeroFunc+"[") {
+					zeroFunc = cfg.zeroFunc
+				}
+			}
+		}
+	}
+
+	typeTmplCompiled := template.New("TypeTmpl").Funcs(funcMapFunc(self, cfg))
+	for _, text := range []string{tmpl, importsTmpl, assertTmpl, logTmpl, zeroTmpl} {
+		template.Must(typeTmplCompiled.Parse(text))
+	}
+
+	// Params must not shadow the receiver, nor the packages the bodies of
+	// the methods taking them refer to.
+	reserved := []string{cfg.RecvName}
+	if tmpl == GomockTmpl {
+		reserved = append(reserved, "gomock", "reflect")
+	}
+	if cfg.Asserts {
+		reserved = append(reserved, "reflect")
+	}
+	var buf bytes.Buffer
+	methods := make([]Method, len(fns))
+	for idx, fn := range fns {
+		fn.Params = nameParams(fn.Params, reserved...)
+		names := append([]string(nil), reserved...)
+		for _, p := range fn.Params {
+			names = append(names, p.Name)
+		}
+		fn.Res = nameResults(fn.Res, names...)
+		methods[idx] = Method{Recv: cfg.RecvName, Func: fn}
+		if cfg.Comment != "" {
+			c, err := RenderComment(cfg.Comment, fn.Name, ifaceName, recvType)
+			if err != nil {
+				return nil, Error{err, ErrUsage}
+			}
+			methods[idx].Comment = c
+		}
+	}
+
+	var structComment string
+	if cfg.StructComment != "" {
+		c, err := RenderComment(cfg.StructComment, "", ifaceName, recvType)
+		if err != nil {
+			return nil, Error{err, ErrUsage}
+		}
+		structComment = c
+	}
+
+	methodsStruct := struct {
+		Methods  []Method
+		Recv     string
+		RecvName string
+		Iface    string
+		Header   string
+		Package  string
+		Imports  []Import
+		Strict   bool
+
+		EmbedIface bool
+		IfaceField string
+		Part       string
+		Capture    bool
+		Dump       bool
+		Asserts    bool
+		Builder    bool
+		Queue      bool
+		Spy        bool
+		Sync       string
+		Log        bool
+		ZeroFunc   string
+
+		ExpectClose   bool
+		StructComment string
+		Generic       bool
+		Concrete      bool
+		Partial       bool
+		Internal      bool
+		TypeParams    string
+		TypeArgs      string
+	}{
+		Methods:  methods,
+		Recv:     recvType,
+		RecvName: cfg.RecvName,
+		Iface:    ifaceName,
+		Header:   cfg.Header,
+		Package:  pkg,
+		Imports:  imps,
+		Strict:   cfg.Strict,
+
+		EmbedIface: cfg.EmbedIface,
+		IfaceField: ifaceField,
+		Part:       cfg.Part,
+		Capture:    cfg.Capture,
+		Dump:       dump,
+		Asserts:    cfg.Asserts,
+		Builder:    cfg.Builder,
+		Queue:      cfg.Queue,
+		Spy:        cfg.Spy,
+		Sync:       cfg.Sync,
+		Log:        cfg.Log,
+		ZeroFunc:   zeroFunc,
+
+		ExpectClose: cfg.ExpectClose,
+
+		StructComment: structComment,
+		Generic:       cfg.Generic,
+		Concrete:      cfg.Concrete,
+		Partial:       cfg.Partial,
+		Internal:      internal,
+		TypeParams:    typeParams,
+		TypeArgs:      typeArgs,
+	}
+
+	if err := typeTmplCompiled.Execute(&buf, &methodsStruct); err != nil {
+		return nil, Error{err, ErrGenerate}
+	}
+	if cfg.Raw {
+		return buf.Bytes(), nil
+	}
+
+	src := buf.Bytes()
+	if cfg.PostProcess != nil || cfg.NoFormat {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+		if err != nil {
+			return nil, Error{fmt.Errorf("%v (set Config.Raw to see the generated code)", err), ErrGenerate}
+		}
+		// Without goimports, the imports of types the code doesn't
+		// mention, e.g. of a generic interface, must be removed here.
+		if cfg.NoFormat {
+			for _, imp := range append([]*ast.ImportSpec(nil), f.Imports...) {
+				path, _ := strconv.Unquote(imp.Path.Value)
+				name := ""
+				if imp.Name != nil {
+					name = imp.Name.Name
+				}
+				if !astutil.UsesImport(f, path) {
+					astutil.DeleteNamedImport(fset, f, name, path)
+				}
+			}
+		}
+		if cfg.PostProcess != nil {
+			if err := cfg.PostProcess(f); err != nil {
+				return nil, err
+			}
+		}
+		var out bytes.Buffer
+		if err := format.Node(&out, fset, f); err != nil {
+			return nil, Error{err, ErrGenerate}
+		}
+		if cfg.NoFormat {
+			return out.Bytes(), nil
+		}
+		src = out.Bytes()
+	}
+
+	pretty, err := imports.Process(cfg.Filename, src, nil)
+	if err != nil {
+		return nil, Error{fmt.Errorf("%v (set Config.Raw to see the generated code)", err), ErrGenerate}
+	}
+	return pretty, nil
+}
@@ -0,0 +1,221 @@
+package testgen
+
+import (
+	"errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// typeCheck fails unless src, a file of the package it declares, type
+// checks against the standard library.
+func typeCheck(t *testing.T, src []byte) *ast.File {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "mock.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	return f
+}
+
+// goTest runs go test on a package of the files, keyed by name, which
+// may import only the standard library.
+func goTest(t *testing.T, files map[string]string) {
+	t.Helper()
+	root, err := ioutil.TempDir("", "testgen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "src", "mocks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "test", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOPATH="+root, "GO111MODULE=off", "GOFLAGS=")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test: %v\n%s", err, out)
+	}
+}
+
+func TestGenerateFromType(t *testing.T) {
+	src, err := GenerateFromType("mocks", "Reader", reflect.TypeOf((*io.Reader)(nil)).Elem(), Config{Header: DefaultHeader})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := typeCheck(t, src)
+	if f.Name.Name != "mocks" {
+		t.Errorf("package %s, want mocks", f.Name.Name)
+	}
+	for _, want := range []string{
+		DefaultHeader,
+		"ReadFunc func(arg0 []uint8) (int, error)",
+		"var _ io.Reader = (*Reader)(nil)",
+		"func (t *Reader) Read(arg0 []uint8) (int, error) {",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q in\n%s", want, src)
+		}
+	}
+	goTest(t, map[string]string{
+		"mock.go": string(src),
+		"mock_test.go": `package mocks
+
+import (
+	"io"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	var r io.Reader = &Reader{ReadFunc: func(p []byte) (int, error) { return copy(p, "hi"), io.EOF }}
+	p := make([]byte, 4)
+	if n, err := r.Read(p); n != 2 || err != io.EOF || string(p[:n]) != "hi" {
+		t.Errorf("Read() = %d, %v", n, err)
+	}
+}
+`,
+	})
+}
+
+func TestGenerateFromTypeNotInterface(t *testing.T) {
+	_, err := GenerateFromType("mocks", "Mock", reflect.TypeOf(0), Config{})
+	if !errors.Is(err, ErrNotInterface) {
+		t.Errorf("got %v, want an ErrNotInterface error", err)
+	}
+}
+
+func TestPostProcess(t *testing.T) {
+	cfg := Config{PostProcess: func(f *ast.File) error {
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.TYPE {
+				decl.Doc.List = append(decl.Doc.List, &ast.Comment{Slash: decl.Doc.End(), Text: "//nolint:all"})
+			}
+		}
+		return nil
+	}}
+	src, err := GenerateFromType("mocks", "Reader", reflect.TypeOf((*io.Reader)(nil)).Elem(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeCheck(t, src)
+	if want := "//nolint:all\ntype Reader struct {"; !strings.Contains(string(src), want) {
+		t.Errorf("missing %q in\n%s", want, src)
+	}
+
+	// The hook also runs on unformatted output.
+	cfg.NoFormat = true
+	if src, err = GenerateFromType("mocks", "Reader", reflect.TypeOf((*io.Reader)(nil)).Elem(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	typeCheck(t, src)
+	if want := "//nolint:all\ntype Reader struct {"; !strings.Contains(string(src), want) {
+		t.Errorf("missing %q with NoFormat in\n%s", want, src)
+	}
+}
+
+func TestPostProcessError(t *testing.T) {
+	want := errors.New("hook failed")
+	cfg := Config{PostProcess: func(*ast.File) error { return want }}
+	if _, err := GenerateFromType("mocks", "Reader", reflect.TypeOf((*io.Reader)(nil)).Elem(), cfg); err != want {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+func TestCommentError(t *testing.T) {
+	for _, cfg := range []Config{
+		{Comment: `{{if eq .Name "Read"}}{{.Bogus}}{{end}}`},
+		{StructComment: "{{.Bogus}}"},
+	} {
+		_, err := GenerateFromType("mocks", "Reader", reflect.TypeOf((*io.Reader)(nil)).Elem(), cfg)
+		if !errors.Is(err, ErrUsage) {
+			t.Errorf("%+v: got %v, want an ErrUsage error", cfg, err)
+		}
+	}
+}
+
+// logged clashes with the Logger field of Config.Log.
+type logged interface {
+	Logger() string
+}
+
+func TestConfigErrors(t *testing.T) {
+	reader := reflect.TypeOf((*io.Reader)(nil)).Elem()
+	for _, tc := range []struct {
+		typ   reflect.Type
+		cfg   Config
+		class error
+		msg   string
+	}{
+		{reader, Config{Returns: map[string][]string{"Read": {"0"}}}, ErrUsage, `Config.Returns["Read"]: Read returns 2 results, got 1`},
+		{reflect.TypeOf((*logged)(nil)).Elem(), Config{Log: true}, ErrGenerate, "Config.Log: testgen.logged has a method Logger"},
+	} {
+		_, err := GenerateFromType("mocks", "Mock", tc.typ, tc.cfg)
+		var terr Error
+		if !errors.As(err, &terr) || terr.Class != tc.class || !errors.Is(err, tc.class) || err.Error() != tc.msg {
+			t.Errorf("%s: got %v, want %q of class %v", tc.typ, err, tc.msg, tc.class)
+		}
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	iface := map[string]string{"io.Reader": "interface"}
+	for _, tc := range []struct {
+		typ   string
+		kinds map[string]string
+		nil   string // with PointerZero "nil"
+		alloc string // with PointerZero "alloc"
+	}{
+		{"int", nil, "0", "0"},
+		{"string", nil, `""`, `""`},
+		{"[4]byte", nil, "[4]byte{}", "[4]byte{}"},
+		{"[3]io.Reader", iface, "[3]io.Reader{}", "[3]io.Reader{}"},
+		{"[2][]int", nil, "[2][]int{}", "[2][]int{}"},
+		{"[]byte", nil, "nil", "nil"},
+		{"[]*bytes.Buffer", nil, "nil", "nil"},
+		{"sort.IntSlice", map[string]string{"sort.IntSlice": "slice"}, "nil", "nil"},
+		{"*[]byte", nil, "nil", "&[]byte{}"},
+		{"*map[string]int", nil, "nil", "&map[string]int{}"},
+		{"*bytes.Buffer", map[string]string{"bytes.Buffer": "struct"}, "nil", "&bytes.Buffer{}"},
+		{"temp.Celsius", map[string]string{"temp.Celsius": "basic"}, "*new(temp.Celsius)", "*new(temp.Celsius)"},
+		{"time.Month", nil, "*new(time.Month)", "*new(time.Month)"},
+		{"*int", nil, "nil", "new(int)"},
+		{"io.Reader", iface, "nil", "nil"},
+		{"*io.Reader", iface, "nil", "nil"},
+		{"any", map[string]string{"any": "interface"}, "nil", "nil"},
+		{"interface{}", nil, "nil", "nil"},
+		{"interface{ Close() error }", nil, "nil", "nil"},
+		{"func(path string) error", nil, "nil", "nil"},
+		{"<-chan int", nil, "nil", "nil"},
+		{"<-chan struct{}", nil, "nil", "nil"},
+		{"chan<- int", nil, "nil", "nil"},
+		{"struct{}", nil, "struct{}{}", "struct{}{}"},
+	} {
+		if got := (Config{PointerZero: "nil"}).zeroValue(tc.typ, tc.kinds); got != tc.nil {
+			t.Errorf("zeroValue(%q) = %s, want %s", tc.typ, got, tc.nil)
+		}
+		if got := (Config{PointerZero: "alloc"}).zeroValue(tc.typ, tc.kinds); got != tc.alloc {
+			t.Errorf("zeroValue(%q) with alloc = %s, want %s", tc.typ, got, tc.alloc)
+		}
+	}
+}
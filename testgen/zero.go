@@ -0,0 +1,187 @@
+package testgen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// namedKey returns the import path and name of the named type, or pointer
+// to a named type, res, e.g. "net/http.Client" or "*net/http.Client", and
+// the package name qualifying it. Types without a qualifier belong to the
+// package with the import path local. It returns "" for other types.
+func namedKey(res Param, local string) (key, qual string) {
+	typ := strings.TrimPrefix(res.Type, "*")
+	name, path := typ, local
+	if dot := strings.Index(typ, "."); dot > 0 {
+		qual, name, path = typ[:dot], typ[dot+1:], res.Imports[typ[:dot]]
+	}
+	if !token.IsIdentifier(name) || !token.IsExported(name) || path == "" {
+		return "", ""
+	}
+	return res.Type[:len(res.Type)-len(typ)] + path + "." + name, qual
+}
+
+// constructors returns the names of the constructors of the named types,
+// or pointers to named types, that fns return, keyed by namedKey: NewT if
+// it returns the type, otherwise Default if it does, as declares reports.
+func constructors(fns []Func, declares func(path, fn, name string, ptr bool) bool) map[string]string {
+	ctors := make(map[string]string)
+	for _, fn := range fns {
+		for _, res := range fn.Res {
+			key, _ := namedKey(res, "")
+			if key == "" {
+				continue
+			}
+			if _, ok := ctors[key]; ok {
+				continue
+			}
+			ctors[key] = "" // not found, unless below
+			typ := strings.TrimPrefix(key, "*")
+			dot := strings.LastIndex(typ, ".")
+			if declares == nil {
+				continue
+			}
+			path, name, ptr := typ[:dot], typ[dot+1:], typ != key
+			if declares(path, "New"+name, name, ptr) {
+				ctors[key] = "New" + name
+			} else if declares(path, "Default", name, ptr) {
+				ctors[key] = "Default"
+			}
+		}
+	}
+	return ctors
+}
+
+// basicZero maps predeclared types to their zero values.
+var basicZero = map[string]string{
+	"bool":       "false",
+	"string":     `""`,
+	"int":        "0",
+	"int8":       "0",
+	"int16":      "0",
+	"int32":      "0",
+	"int64":      "0",
+	"uint":       "0",
+	"uint8":      "0",
+	"uint16":     "0",
+	"uint32":     "0",
+	"uint64":     "0",
+	"uintptr":    "0",
+	"byte":       "0",
+	"rune":       "0",
+	"float32":    "0",
+	"float64":    "0",
+	"complex64":  "0",
+	"complex128": "0",
+}
+
+// stdDefaults maps standard library types, whose packages are imported
+// under their paths, to better defaults than their zero values.
+var stdDefaults = map[string]string{
+	"context.Context":    "context.Background()",
+	"context.CancelFunc": "func() {}",
+}
+
+// zeroValue returns an expression for the zero value of the type typ,
+// where kinds holds the kinds of the named types in typ.
+// Examples, with PointerZero set to "alloc":
+//
+//	zeroValue("int") => "0"
+//	zeroValue("[4]byte") => "[4]byte{}"
+//	zeroValue("[]*bytes.Buffer") => "nil"
+//	zeroValue("*bytes.Buffer") => "&bytes.Buffer{}"
+//	zeroValue("*int") => "new(int)"
+//	zeroValue("*io.Reader") => "nil"
+//	zeroValue("func(path string) error") => "nil"
+//	zeroValue("<-chan struct{}") => "nil"
+//	zeroValue("struct{}") => "struct{}{}"
+func (c Config) zeroValue(typ string, kinds map[string]string) string {
+	e, err := parser.ParseExpr(typ)
+	if err != nil {
+		return typ + "{}"
+	}
+	return c.zero(e, kinds)
+}
+
+func (c Config) zero(e ast.Expr, kinds map[string]string) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		if z, ok := basicZero[t.Name]; ok {
+			return z
+		}
+	case *ast.InterfaceType:
+		// e.g. interface{}
+		return "nil"
+	case *ast.FuncType, *ast.ChanType:
+		// e.g. func(path string) error, which has no literal zero value
+		return "nil"
+	case *ast.ArrayType:
+		// Slices are nil, while arrays, e.g. [3]io.Reader, are composite
+		// literals of zero elements.
+		if t.Len == nil {
+			return "nil"
+		}
+	case *ast.StarExpr:
+		// There is no literal for a pointer to an interface.
+		if c.PointerZero != "alloc" || kinds[types.ExprString(t.X)] == "interface" {
+			return "nil"
+		}
+		// &T{} is only valid for composite types; anything else,
+		// e.g. *int or **T, is allocated with new.
+		if composite(t.X, kinds) {
+			return "&" + types.ExprString(t.X) + "{}"
+		}
+		return "new(" + types.ExprString(t.X) + ")"
+	}
+	if name := typeName(e); name != "" {
+		if kinds[name] == "interface" || kinds[name] == "slice" {
+			return "nil"
+		}
+		if c.zeroFunc != "" {
+			return c.zeroFunc + "[" + types.ExprString(e) + "]()"
+		}
+		if composite(e, kinds) {
+			return types.ExprString(e) + "{}"
+		}
+		// e.g. a defined numeric type, or one whose kind is unknown
+		return "*new(" + types.ExprString(e) + ")"
+	}
+	if c.zeroFunc != "" {
+		return c.zeroFunc + "[" + types.ExprString(e) + "]()"
+	}
+	return types.ExprString(e) + "{}"
+}
+
+// typeName returns the name of the named type e as kinds are keyed,
+// without type arguments, or "" if e is not a named type.
+func typeName(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		return types.ExprString(e)
+	case *ast.IndexExpr:
+		return typeName(t.X)
+	case *ast.IndexListExpr:
+		return typeName(t.X)
+	}
+	return ""
+}
+
+// composite reports whether e can be used in a composite literal,
+// where kinds holds the kinds of the named types in e.
+func composite(e ast.Expr, kinds map[string]string) bool {
+	if name := typeName(e); name != "" {
+		switch kinds[name] {
+		case "struct", "array", "slice", "map":
+			return true
+		}
+		return false
+	}
+	switch e.(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.StructType:
+		return true
+	}
+	return false
+}